@@ -1,23 +1,186 @@
-// This file will initialize our application and start the server.
-
-package main
-
-import (
-	"net/http"
-	"Smart-Music-Go/pkg/handlers"
-)
-
-func main() {
-	// Initialize a new http.ServeMux, which is basically a HTTP request router (or multiplexer)
-	mux := http.NewServeMux()
-
-	// Initialize a new instance of application which contains pointers to our two handler methods
-	app := &handlers.Application{}
-
-	// Register the two URL patterns and their corresponding handler functions to the router
-	mux.HandleFunc("/", app.Home)
-	mux.HandleFunc("/search", app.Search)
-
-	// Start the HTTP server
-	http.ListenAndServe(":4000", mux)
-}
+// This file will initialize our application and start the server.
+
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"Smart-Music-Go/pkg/bio"
+	"Smart-Music-Go/pkg/config"
+	"Smart-Music-Go/pkg/events"
+	"Smart-Music-Go/pkg/features"
+	"Smart-Music-Go/pkg/handlers"
+	"Smart-Music-Go/pkg/logging"
+	"Smart-Music-Go/pkg/middleware"
+	"Smart-Music-Go/pkg/music"
+	"Smart-Music-Go/pkg/quota"
+	"Smart-Music-Go/pkg/spotify"
+	"Smart-Music-Go/pkg/waveform"
+)
+
+func main() {
+	// Parse the command line flags
+	configPath := flag.String("config", "", "path to a YAML configuration file (environment variables override it)")
+	checkConfig := flag.Bool("check-config", false, "validate the configuration and exit")
+	verifyServices := flag.Bool("verify", false, "check the credentials and services in the configuration, print a report and exit")
+	flag.Parse()
+
+	// Load the configuration from the file (if any) and the environment
+	// Until it is loaded, problems are reported with the default text logger
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatal(slog.Default(), "loading configuration", err)
+	}
+
+	// Create the logger shared by the whole application
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fatal(slog.Default(), "creating logger", err)
+	}
+	slog.SetDefault(logger)
+
+	// When only checking the configuration, report success and stop here
+	if *checkConfig {
+		fmt.Println("configuration OK")
+		os.Exit(0)
+	}
+
+	// When verifying, try every configured service and exit with the result
+	if *verifyServices {
+		if !verify(cfg, os.Stdout) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Initialize a new http.ServeMux, which is basically a HTTP request router (or multiplexer)
+	mux := http.NewServeMux()
+
+	// Connect to Redis when configured, so the cache and rate limits are shared between instances
+	rdb, err := newRedis(cfg)
+	if err != nil {
+		fatal(logger, "connecting to redis", err)
+	}
+
+	// Count every outbound call and stop calling a provider once its quota is used up
+	quotas := quota.NewManager(map[string]quota.Limit{
+		"spotify": {Daily: cfg.Spotify.DailyQuota, PerWindow: cfg.Spotify.WindowQuota, Window: cfg.Spotify.QuotaWindow},
+	})
+
+	// Create the Spotify client shared by all requests
+	sc := spotify.NewSpotifyClient(cfg.Spotify.ClientID, cfg.Spotify.ClientSecret, &quota.Transport{Provider: "spotify", Manager: quotas})
+	sc.Logger = logger
+	if store := newSearchCache(cfg, rdb); store != nil {
+		sc.EnableSearchCache(store)
+	}
+	sc.EnableAnalysisCache(newAnalysisCache(rdb))
+
+	// Initialize a new instance of application which contains the dependencies of our handler methods
+	// The flags were validated with the configuration
+	flags, err := features.New(cfg.Features)
+	if err != nil {
+		fatal(logger, "loading feature flags", err)
+	}
+
+	app := &handlers.Application{
+		Features:       flags,
+		Spotify:        sc,
+		DefaultMarket:  cfg.Spotify.DefaultMarket,
+		Dictionary:     music.NewDictionary(dictionaryWords),
+		SearchCacheTTL: cfg.SearchCache.TTL,
+		Errors:         handlers.NewErrorLog(100),
+		AdminToken:     cfg.AdminToken,
+		CookieKey:      cookieKey(cfg, logger),
+		Started:        time.Now(),
+		Logger:         logger,
+		Quotas:         quotas,
+	}
+
+	// Previews are downloaded from Spotify's CDN, which is counted apart from the API quota
+	app.Waveforms = waveform.New(&quota.Transport{Provider: "spotify-previews", Manager: quotas})
+	app.Waveforms.EnableCache(newWaveformCache(rdb))
+
+	// Biographies come from Wikipedia, which needs no credentials
+	wikipedia := bio.NewWikipedia(&quota.Transport{Provider: "wikipedia", Manager: quotas})
+	wikipedia.EnableCache(newBioCache(rdb))
+	app.Bios = wikipedia
+
+	// Concert listings are optional, they need a Bandsintown app ID
+	if id := cfg.Events.BandsintownAppID; id != "" {
+		bandsintown := events.NewBandsintown(id, &quota.Transport{Provider: "bandsintown", Manager: quotas})
+		bandsintown.EnableCache(newEventsCache(rdb))
+		app.Events = bandsintown
+	}
+
+	// Routes that call external APIs are rate limited per client and server-wide
+	limit := middleware.RateLimit(
+		newLimiter(rdb, "global", cfg.RateLimit.GlobalPerMinute, cfg.RateLimit.GlobalBurst),
+		newLimiter(rdb, "ip", cfg.RateLimit.PerIPPerMinute, cfg.RateLimit.PerIPBurst),
+		logger,
+	)
+
+	// Register the URL patterns and their corresponding handler functions to the router
+	mux.HandleFunc("/", app.Home)
+	mux.Handle("/search", limit(http.HandlerFunc(app.Search)))
+	mux.HandleFunc("POST /theme", app.SetTheme)
+	mux.Handle("GET /api/search", limit(http.HandlerFunc(app.SearchJSON)))
+	mux.Handle("GET /api/search/all", app.RequireFeature("search_all", limit(http.HandlerFunc(app.SearchAll))))
+	mux.Handle("GET /api/search/suggest", app.RequireFeature("search_suggest", limit(http.HandlerFunc(app.Suggest))))
+	mux.Handle("GET /api/playlists/{id}/tracks", limit(http.HandlerFunc(app.PlaylistTracks)))
+	mux.Handle("GET /api/albums/{id}", limit(http.HandlerFunc(app.Album)))
+	mux.Handle("GET /api/artists/{id}", limit(http.HandlerFunc(app.Artist)))
+	mux.Handle("GET /api/tracks/{id}/analysis", limit(http.HandlerFunc(app.TrackAnalysis)))
+	mux.Handle("GET /api/tracks/{id}/waveform", app.RequireFeature("waveform", limit(http.HandlerFunc(app.TrackWaveform))))
+	mux.Handle("GET /api/events", app.RequireFeature("events", limit(http.HandlerFunc(app.ArtistEvents))))
+
+	// Admin endpoints, only served when an admin token is configured
+	// They are rate limited too, so the token can't be guessed by trying many of them
+	mux.Handle("/admin", limit(app.RequireAdmin(app.AdminDashboard)))
+	mux.Handle("/api/admin/status", limit(app.RequireAdmin(app.AdminStatusJSON)))
+	mux.Handle("/api/admin/errors", limit(app.RequireAdmin(app.AdminErrorsJSON)))
+	mux.Handle("/api/admin/quotas", limit(app.RequireAdmin(app.AdminQuotasJSON)))
+
+	// Behind a reverse proxy, take the client address, scheme and host from the forwarded headers
+	trusted, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		fatal(logger, "parsing trusted proxies", err)
+	}
+	// Every request goes through the size and concurrency protections, which need the client IP
+	concurrency := middleware.NewConcurrencyLimiter(cfg.Limits.MaxInFlight, cfg.Limits.MaxInFlightPerClient, cfg.Limits.RetryAfter)
+	var handler http.Handler = mux
+	handler = middleware.MaxBodySize(cfg.Limits.MaxBodyBytes)(handler)
+	handler = concurrency.Middleware(handler)
+	handler = middleware.Forwarded(trusted)(handler)
+
+	// Start the HTTP server, over TLS when it is configured
+	logger.Info("starting server", "addr", cfg.Addr, "tls", cfg.TLS.Enabled())
+	fatal(logger, "server stopped", serve(cfg, handler, logger))
+}
+
+// fatal logs err at error level and exits with a non-zero status
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// cookieKey returns the key signing cookies: the configured secret, or a random key lost when the server stops.
+// A random key is only good for a single instance, others couldn't read the cookies it signs.
+func cookieKey(cfg config.Config, logger *slog.Logger) []byte {
+	if cfg.CookieSecret != "" {
+		return []byte(cfg.CookieSecret)
+	}
+	if cfg.RedisURL != "" {
+		logger.Warn("cookie_secret is not set, flash messages may be lost between instances")
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		fatal(logger, "generating the cookie key", err)
+	}
+	return key
+}
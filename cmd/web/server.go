@@ -0,0 +1,82 @@
+// This file will configure the HTTP server, including optional TLS and Let's Encrypt support.
+
+package main
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+
+	"Smart-Music-Go/pkg/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// serve starts the HTTP server on cfg.Addr and blocks until it stops.
+// When TLS is configured the server speaks HTTPS, sends HSTS headers and,
+// if a redirect address is set, also listens on plain HTTP to redirect clients to HTTPS.
+// Errors reported by net/http itself are written to logger.
+func serve(cfg config.Config, handler http.Handler, logger *slog.Logger) error {
+	errorLog := slog.NewLogLogger(logger.Handler(), slog.LevelError)
+	srv := &http.Server{Addr: cfg.Addr, Handler: handler, ErrorLog: errorLog}
+
+	// Without TLS there is nothing else to set up
+	if !cfg.TLS.Enabled() {
+		return srv.ListenAndServe()
+	}
+
+	srv.Handler = hsts(handler)
+	redirect := redirectToHTTPS(cfg.Addr)
+
+	if cfg.TLS.Autocert.Enabled() {
+		// Certificates are requested from Let's Encrypt on the first handshake for an allowed domain
+		// and stored in the cache directory so they survive restarts
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLS.Autocert.Domains...),
+			Cache:      autocert.DirCache(cfg.TLS.Autocert.CacheDir),
+			Email:      cfg.TLS.Autocert.Email,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		// The plain HTTP listener must answer the ACME challenges before redirecting
+		redirect = m.HTTPHandler(redirect)
+	}
+
+	if cfg.TLS.RedirectAddr != "" {
+		go func() {
+			// A failing redirect listener should not take HTTPS down with it
+			redirectSrv := &http.Server{Addr: cfg.TLS.RedirectAddr, Handler: redirect, ErrorLog: errorLog}
+			if err := redirectSrv.ListenAndServe(); err != nil {
+				logger.Error("HTTP redirect listener stopped", "addr", cfg.TLS.RedirectAddr, "err", err)
+			}
+		}()
+	}
+
+	// With autocert the certificate comes from TLSConfig, so both file names are empty
+	return srv.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+}
+
+// hsts tells browsers to only use HTTPS for this host from now on.
+func hsts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=31536000")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// redirectToHTTPS returns a handler that permanently redirects every request
+// to the same URL over HTTPS, on the port of tlsAddr.
+func redirectToHTTPS(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		// The default HTTPS port is left out of the URL
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
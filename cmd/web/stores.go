@@ -0,0 +1,122 @@
+// This file will build the components holding shared state, in Redis when it is configured and in memory otherwise.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Smart-Music-Go/pkg/cache"
+	"Smart-Music-Go/pkg/config"
+	"Smart-Music-Go/pkg/middleware"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix namespaces every key the application writes to Redis
+const redisKeyPrefix = "smartmusic:"
+
+// newRedis connects to the Redis server at cfg.RedisURL and checks that it answers.
+// It returns nil when Redis is not configured.
+func newRedis(cfg config.Config) (*redis.Client, error) {
+	if cfg.RedisURL == "" {
+		return nil, nil
+	}
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing redis_url: %w", err)
+	}
+	rdb := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+	return rdb, nil
+}
+
+// newSearchCache returns the store of cached search results, or nil when the cache is disabled
+func newSearchCache(cfg config.Config, rdb *redis.Client) cache.Store {
+	if cfg.SearchCache.TTL <= 0 {
+		return nil
+	}
+	if rdb != nil {
+		return cache.NewRedisStore(rdb, redisKeyPrefix+"search:", cfg.SearchCache.TTL)
+	}
+	return cache.NewMemory(cfg.SearchCache.TTL, cfg.SearchCache.MaxEntries)
+}
+
+// Track analyses never change, so they are kept for a week.
+// The version in the Redis key changes with the fields of music.Analysis, so older entries aren't read back with missing fields.
+const (
+	analysisCacheKey        = "analysis:v2:"
+	analysisCacheTTL        = 7 * 24 * time.Hour
+	analysisCacheMaxEntries = 10000
+)
+
+// newAnalysisCache returns the store of cached track analyses
+func newAnalysisCache(rdb *redis.Client) cache.Store {
+	if rdb != nil {
+		return cache.NewRedisStore(rdb, redisKeyPrefix+analysisCacheKey, analysisCacheTTL)
+	}
+	return cache.NewMemory(analysisCacheTTL, analysisCacheMaxEntries)
+}
+
+// Waveforms are computed from previews, which don't change
+const (
+	waveformCacheTTL        = 7 * 24 * time.Hour
+	waveformCacheMaxEntries = 10000
+)
+
+// newWaveformCache returns the store of computed track waveforms
+func newWaveformCache(rdb *redis.Client) cache.Store {
+	if rdb != nil {
+		return cache.NewRedisStore(rdb, redisKeyPrefix+"waveform:", waveformCacheTTL)
+	}
+	return cache.NewMemory(waveformCacheTTL, waveformCacheMaxEntries)
+}
+
+// Biographies are edited now and then, and most artists have the same one for years
+const (
+	bioCacheTTL        = 24 * time.Hour
+	bioCacheMaxEntries = 5000
+)
+
+// newBioCache returns the store of cached artist biographies
+func newBioCache(rdb *redis.Client) cache.Store {
+	if rdb != nil {
+		return cache.NewRedisStore(rdb, redisKeyPrefix+"bio:", bioCacheTTL)
+	}
+	return cache.NewMemory(bioCacheTTL, bioCacheMaxEntries)
+}
+
+// Concert listings change a few times a day at most
+const (
+	eventsCacheTTL        = time.Hour
+	eventsCacheMaxEntries = 1000
+)
+
+// newEventsCache returns the store of cached artist events
+func newEventsCache(rdb *redis.Client) cache.Store {
+	if rdb != nil {
+		return cache.NewRedisStore(rdb, redisKeyPrefix+"events:", eventsCacheTTL)
+	}
+	return cache.NewMemory(eventsCacheTTL, eventsCacheMaxEntries)
+}
+
+// dictionaryWords bounds the memory used by the dictionary correcting misspelled searches.
+// It is kept in memory by each instance, correcting typos only needs the common words.
+const dictionaryWords = 50000
+
+// newLimiter returns a rate limiter named name, or nil when perMinute disables it
+func newLimiter(rdb *redis.Client, name string, perMinute, burst int) middleware.Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if rdb != nil {
+		return middleware.NewRedisLimiter(rdb, redisKeyPrefix+"ratelimit:"+name+":", perMinute, burst)
+	}
+	return middleware.NewRateLimiter(perMinute, burst)
+}
@@ -0,0 +1,98 @@
+// This file will check that the services the configuration points to can actually be used, for the -verify flag.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"Smart-Music-Go/pkg/config"
+	"Smart-Music-Go/pkg/events"
+	"Smart-Music-Go/pkg/spotify"
+)
+
+// verifyTimeout bounds each check, so an unreachable service is reported instead of hanging
+const verifyTimeout = 10 * time.Second
+
+// check is a single verification, skipped when the feature it covers isn't configured
+type check struct {
+	name string
+	// skip explains why the check doesn't apply, empty when it should run
+	skip string
+	run  func(ctx context.Context) error
+}
+
+// verify runs every check against the configured services and prints a readiness report to w.
+// It reports whether they all passed.
+func verify(cfg config.Config, w io.Writer) bool {
+	checks := []check{
+		{name: "spotify credentials", run: func(ctx context.Context) error {
+			return spotify.NewSpotifyClient(cfg.Spotify.ClientID, cfg.Spotify.ClientSecret, nil).CheckCredentials(ctx)
+		}},
+		{name: "redis", skip: skipUnless(cfg.RedisURL != "", "redis_url is not set"), run: func(ctx context.Context) error {
+			rdb, err := newRedis(cfg)
+			if err != nil {
+				return err
+			}
+			defer rdb.Close()
+			// Reading is not enough, the cache and rate limits write
+			return rdb.Set(ctx, redisKeyPrefix+"verify", time.Now().String(), time.Minute).Err()
+		}},
+		{name: "tls certificate", skip: skipUnless(cfg.TLS.CertFile != "", "tls.cert_file is not set"), run: func(ctx context.Context) error {
+			_, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+			return err
+		}},
+		{name: "autocert cache directory", skip: skipUnless(cfg.TLS.Autocert.Enabled(), "tls.autocert.domains is not set"), run: func(ctx context.Context) error {
+			return checkWritable(cfg.TLS.Autocert.CacheDir)
+		}},
+		{name: "bandsintown", skip: skipUnless(cfg.Events.BandsintownAppID != "", "events.bandsintown_app_id is not set"), run: func(ctx context.Context) error {
+			// Any artist will do, an app ID Bandsintown doesn't accept fails whatever the artist
+			_, err := events.NewBandsintown(cfg.Events.BandsintownAppID, nil).ArtistEvents(ctx, "Daft Punk")
+			return err
+		}},
+	}
+
+	ok := true
+	for _, c := range checks {
+		if c.skip != "" {
+			fmt.Fprintf(w, "SKIP  %s (%s)\n", c.name, c.skip)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+		err := c.run(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(w, "FAIL  %s: %v\n", c.name, err)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "OK    %s\n", c.name)
+	}
+	return ok
+}
+
+// skipUnless returns reason when the check doesn't apply, and an empty string when it does
+func skipUnless(applies bool, reason string) string {
+	if applies {
+		return ""
+	}
+	return reason
+}
+
+// checkWritable creates dir if needed and checks that files can be written to it
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".verify-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
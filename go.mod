@@ -0,0 +1,19 @@
+module Smart-Music-Go
+
+go 1.22
+
+require (
+	github.com/redis/go-redis/v9 v9.7.0
+	github.com/zmb3/spotify/v2 v2.4.3
+	golang.org/x/crypto v0.31.0
+	golang.org/x/oauth2 v0.21.0
+	golang.org/x/sync v0.10.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/net v0.23.0 // indirect
+)
@@ -0,0 +1,15 @@
+// This file will describe the providers of artist biographies.
+
+package bio
+
+import (
+	"context"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// Provider finds the biography of artists, e.g. Wikipedia
+type Provider interface {
+	// ArtistBio returns a short biography of the artist with the given name, nil when the provider has none
+	ArtistBio(ctx context.Context, name string) (*music.Bio, error)
+}
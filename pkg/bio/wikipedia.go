@@ -0,0 +1,169 @@
+// This file will contain the client of the Wikipedia page summary API, used for artist biographies.
+
+package bio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/cache"
+	"Smart-Music-Go/pkg/music"
+)
+
+// wikipediaURL is the base URL of the REST API of the English Wikipedia
+const wikipediaURL = "https://en.wikipedia.org/api/rest_v1"
+
+// userAgent identifies the application, as Wikimedia asks of API clients
+const userAgent = "Smart-Music-Go (https://github.com/AusBoone/Smart-Music-Go)"
+
+const (
+	// lookupTimeout bounds the whole lookup of a biography, which can take a call for each of the disambiguations
+	lookupTimeout = 10 * time.Second
+	// maxSummaryBytes bounds the page summaries read, they are a few kilobytes
+	maxSummaryBytes = 256 << 10
+)
+
+// disambiguations are appended to an artist's name when the plain name is another page, e.g. Queen (band)
+var disambiguations = []string{"", " (band)", " (musician)", " (singer)", " (rapper)"}
+
+// musicWords are the words of a page description that show the page is about musicians
+var musicWords = map[string]bool{
+	"band": true, "duo": true, "trio": true, "group": true, "singer": true, "rapper": true, "dj": true,
+	"composer": true, "songwriter": true, "producer": true, "vocalist": true, "guitarist": true,
+	"pianist": true, "drummer": true, "orchestra": true, "ensemble": true, "choir": true,
+}
+
+// Wikipedia finds biographies in the English Wikipedia.
+// See https://en.wikipedia.org/api/rest_v1/#/Page%20content/get_page_summary__title_
+type Wikipedia struct {
+	client *http.Client
+	// cache keeps the biography of each artist when EnableCache was called, nil otherwise
+	cache cache.Store
+}
+
+// NewWikipedia creates a Wikipedia client. API calls go through base, which may be nil to use http.DefaultTransport.
+func NewWikipedia(base http.RoundTripper) *Wikipedia {
+	return &Wikipedia{client: &http.Client{Transport: base}}
+}
+
+// EnableCache keeps the biography of each artist in store.
+// Summaries are edited now and then, so a TTL of a day or so is plenty.
+func (w *Wikipedia) EnableCache(store cache.Store) {
+	w.cache = store
+}
+
+// wikipediaSummary is a page summary as returned by the Wikipedia API
+type wikipediaSummary struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Extract     string `json:"extract"`
+	URLs        struct {
+		Desktop struct {
+			Page string `json:"page"`
+		} `json:"desktop"`
+	} `json:"content_urls"`
+}
+
+// ArtistBio returns the summary of the Wikipedia page about the artist.
+// Pages whose description isn't about music are skipped, so an artist called Queen doesn't get the biography of a monarch;
+// the name followed by (band), (musician) and so on is tried instead.
+// The calls share a single deadline, lookupTimeout.
+func (w *Wikipedia) ArtistBio(ctx context.Context, name string) (*music.Bio, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if cached, ok := w.cachedBio(ctx, key); ok {
+		return cached, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	var found *music.Bio
+	for _, suffix := range disambiguations {
+		summary, ok, err := w.summary(ctx, name+suffix)
+		if err != nil {
+			return nil, err
+		}
+		if ok && summary.Type == "standard" && aboutMusic(summary.Description) {
+			found = &music.Bio{Summary: summary.Extract, URL: summary.URLs.Desktop.Page, Source: "wikipedia"}
+			break
+		}
+	}
+	w.cacheBio(ctx, key, found)
+	return found, nil
+}
+
+// summary fetches the summary of the page with the given title, following redirects.
+// It reports false when there is no such page.
+func (w *Wikipedia) summary(ctx context.Context, title string) (wikipediaSummary, bool, error) {
+	endpoint := wikipediaURL + "/page/summary/" + url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return wikipediaSummary{}, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return wikipediaSummary{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return wikipediaSummary{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return wikipediaSummary{}, false, fmt.Errorf("wikipedia: unexpected status %s", resp.Status)
+	}
+	var summary wikipediaSummary
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSummaryBytes)).Decode(&summary); err != nil {
+		return wikipediaSummary{}, false, fmt.Errorf("wikipedia: decoding summary: %w", err)
+	}
+	return summary, true, nil
+}
+
+// aboutMusic reports whether a page description, e.g. "British rock band", is about musicians
+func aboutMusic(description string) bool {
+	description = strings.ToLower(description)
+	if strings.Contains(description, "music") {
+		return true
+	}
+	for _, word := range strings.FieldsFunc(description, func(r rune) bool { return r == ' ' || r == ',' || r == '-' }) {
+		if musicWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedBio returns the cached biography of an artist, treating cache failures as misses.
+// Artists without a biography are cached too, as a null.
+func (w *Wikipedia) cachedBio(ctx context.Context, key string) (*music.Bio, bool) {
+	if w.cache == nil {
+		return nil, false
+	}
+	data, ok, err := w.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var bio *music.Bio
+	if err := json.Unmarshal(data, &bio); err != nil {
+		return nil, false
+	}
+	return bio, true
+}
+
+// cacheBio stores the biography of an artist, ignoring cache failures
+func (w *Wikipedia) cacheBio(ctx context.Context, key string, bio *music.Bio) {
+	if w.cache == nil {
+		return
+	}
+	if data, err := json.Marshal(bio); err == nil {
+		_ = w.cache.Set(ctx, key, data)
+	}
+}
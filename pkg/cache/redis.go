@@ -0,0 +1,41 @@
+// This file will implement the cache storage on top of Redis so several instances can share it.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store backed by Redis. Keys are namespaced with a prefix so
+// several caches (and applications) can share a database.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisStore returns a Store saving values under prefix in Redis for ttl
+func NewRedisStore(client *redis.Client, prefix string, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Get implements Store
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// Set implements Store
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte) error {
+	return s.client.Set(ctx, s.prefix+key, value, s.ttl).Err()
+}
@@ -0,0 +1,39 @@
+// This file will define the cache storage interface and its in-memory implementation.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a key/value cache whose entries expire on their own.
+// The in-memory Store suits a single instance; RedisStore is shared by every instance behind a load balancer.
+type Store interface {
+	// Get returns the value stored under key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key until the store's TTL elapses
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// Memory is a Store keeping values in the memory of the current process
+type Memory struct {
+	items *TTL[[]byte]
+}
+
+// NewMemory returns an in-memory Store keeping values for ttl, up to maxEntries of them
+func NewMemory(ttl time.Duration, maxEntries int) *Memory {
+	return &Memory{items: NewTTL[[]byte](ttl, maxEntries)}
+}
+
+// Get implements Store
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	v, ok := m.items.Get(key)
+	return v, ok, nil
+}
+
+// Set implements Store
+func (m *Memory) Set(_ context.Context, key string, value []byte) error {
+	m.items.Set(key, value)
+	return nil
+}
@@ -0,0 +1,90 @@
+// This file will contain a small in-memory cache whose entries expire after a fixed time.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// entry is a cached value and the time it stops being valid
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// TTL is a concurrency-safe in-memory cache.
+// Entries expire ttl after they were set, and the cache never holds more than maxEntries values.
+type TTL[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	items      map[string]*list.Element
+	// order holds the entries from the first to expire to the last.
+	// Every entry lives for the same ttl, so this is the order they were set in.
+	order *list.List
+	now   func() time.Time
+}
+
+// NewTTL returns an empty cache keeping values for ttl and holding at most maxEntries of them
+func NewTTL[V any](ttl time.Duration, maxEntries int) *TTL[V] {
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+	return &TTL[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored under key, if it is present and has not expired
+func (c *TTL[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok || !c.now().Before(el.Value.(*entry[V]).expires) {
+		var zero V
+		return zero, false
+	}
+	return el.Value.(*entry[V]).value, true
+}
+
+// Set stores value under key, making room first if the cache is full
+func (c *TTL[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if el, exists := c.items[key]; exists {
+		e := el.Value.(*entry[V])
+		e.value, e.expires = value, now.Add(c.ttl)
+		c.order.MoveToBack(el)
+		return
+	}
+	if len(c.items) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.items[key] = c.order.PushBack(&entry[V]{key: key, value: value, expires: now.Add(c.ttl)})
+}
+
+// evict removes every expired entry, or the entry closest to expiring if none has expired.
+// Both are found at the front of c.order, so it only looks at the entries it removes.
+// The caller must hold c.mu.
+func (c *TTL[V]) evict(now time.Time) {
+	c.remove(c.order.Front())
+	for el := c.order.Front(); el != nil && !now.Before(el.Value.(*entry[V]).expires); el = c.order.Front() {
+		c.remove(el)
+	}
+}
+
+// remove drops the entry of el. The caller must hold c.mu.
+func (c *TTL[V]) remove(el *list.Element) {
+	delete(c.items, el.Value.(*entry[V]).key)
+	c.order.Remove(el)
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	type op struct {
+		// advance moves the clock forward before the operation
+		advance time.Duration
+		// set stores key when true, otherwise key is read
+		set  bool
+		key  string
+		want bool
+	}
+	tests := []struct {
+		name string
+		max  int
+		ops  []op
+	}{
+		{"expiry", 10, []op{
+			{0, true, "a", true},
+			{59 * time.Second, false, "a", true},
+			{time.Second, false, "a", false},
+		}},
+		{"set again restarts the TTL", 10, []op{
+			{0, true, "a", true},
+			{30 * time.Second, true, "a", true},
+			{45 * time.Second, false, "a", true},
+		}},
+		{"full cache evicts the oldest", 2, []op{
+			{0, true, "a", true},
+			{time.Second, true, "b", true},
+			{time.Second, true, "c", true},
+			{0, false, "a", false},
+			{0, false, "b", true},
+			{0, false, "c", true},
+		}},
+		{"set again makes an entry the newest", 2, []op{
+			{0, true, "a", true},
+			{time.Second, true, "b", true},
+			{time.Second, true, "a", true},
+			{time.Second, true, "c", true},
+			{0, false, "a", true},
+			{0, false, "b", false},
+		}},
+		{"full cache drops every expired entry", 3, []op{
+			{0, true, "a", true},
+			{time.Second, true, "b", true},
+			{30 * time.Second, true, "c", true},
+			{30 * time.Second, true, "d", true},
+			{0, true, "e", true},
+			{0, false, "c", true},
+			{0, false, "d", true},
+			{0, false, "e", true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			c := NewTTL[string](time.Minute, tt.max)
+			c.now = func() time.Time { return now }
+			for i, o := range tt.ops {
+				now = now.Add(o.advance)
+				if o.set {
+					c.Set(o.key, o.key)
+					continue
+				}
+				if v, ok := c.Get(o.key); ok != o.want || (ok && v != o.key) {
+					t.Fatalf("op %d: Get(%q) = %q, %v, want %v", i, o.key, v, ok, o.want)
+				}
+			}
+			if len(c.items) != c.order.Len() || len(c.items) > tt.max {
+				t.Errorf("%d items and %d ordered entries, want the same and at most %d", len(c.items), c.order.Len(), tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,428 @@
+// This file will load the application configuration from an optional YAML file and the environment.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/features"
+	"Smart-Music-Go/pkg/logging"
+	"Smart-Music-Go/pkg/music"
+
+	"gopkg.in/yaml.v3"
+)
+
+// minSecretLength is the shortest admin token or cookie secret accepted, shorter ones are too easy to guess
+const minSecretLength = 32
+
+// Config holds every setting the web server needs to start
+type Config struct {
+	// Addr is the TCP address the HTTP server listens on, e.g. ":4000"
+	Addr    string        `yaml:"addr"`
+	Spotify SpotifyConfig `yaml:"spotify"`
+	TLS     TLSConfig     `yaml:"tls"`
+	// TrustedProxies lists the IP addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-* headers are believed. Empty means no proxy is trusted.
+	TrustedProxies []string          `yaml:"trusted_proxies"`
+	RateLimit      RateLimitConfig   `yaml:"rate_limit"`
+	Limits         LimitsConfig      `yaml:"limits"`
+	SearchCache    SearchCacheConfig `yaml:"search_cache"`
+	Log            LogConfig         `yaml:"log"`
+	Events         EventsConfig      `yaml:"events"`
+	// Features turns optional features on or off by name, see features.Known for the names and defaults
+	Features map[string]bool `yaml:"features"`
+	// RedisURL (e.g. redis://localhost:6379/0) moves the search cache and rate limits to Redis,
+	// so several instances can run behind a load balancer. Empty keeps them in memory.
+	RedisURL string `yaml:"redis_url"`
+	// AdminToken protects the admin endpoints. They are disabled when it is empty.
+	AdminToken string `yaml:"admin_token"`
+	// CookieSecret signs the cookies set by the server. Instances behind the same load balancer must share it.
+	// Empty uses a random key, so cookies don't outlive a restart.
+	CookieSecret string `yaml:"cookie_secret"`
+}
+
+// SpotifyConfig holds the credentials of the Spotify application
+type SpotifyConfig struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// DefaultMarket is the country code (e.g. "US") used when a request doesn't pick one, empty for none
+	DefaultMarket string `yaml:"default_market"`
+	// DailyQuota caps the API calls per UTC day, zero means unlimited
+	DailyQuota int `yaml:"daily_quota"`
+	// WindowQuota caps the API calls in each QuotaWindow, zero means unlimited
+	WindowQuota int           `yaml:"window_quota"`
+	QuotaWindow time.Duration `yaml:"quota_window"`
+}
+
+// TLSConfig controls HTTPS serving.
+// Either a certificate/key pair or autocert (Let's Encrypt) can be used, but not both.
+type TLSConfig struct {
+	CertFile string         `yaml:"cert_file"`
+	KeyFile  string         `yaml:"key_file"`
+	Autocert AutocertConfig `yaml:"autocert"`
+	// RedirectAddr is an optional plain HTTP address (usually ":80") that redirects to HTTPS.
+	// With autocert it also answers the ACME HTTP challenges.
+	RedirectAddr string `yaml:"redirect_addr"`
+}
+
+// AutocertConfig holds the Let's Encrypt settings
+type AutocertConfig struct {
+	// Domains is the allowlist of host names certificates are requested for.
+	// Autocert is enabled when it is not empty.
+	Domains  []string `yaml:"domains"`
+	CacheDir string   `yaml:"cache_dir"`
+	Email    string   `yaml:"email"`
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.Autocert.Enabled()
+}
+
+// Enabled reports whether certificates should be obtained from Let's Encrypt
+func (a AutocertConfig) Enabled() bool {
+	return len(a.Domains) > 0
+}
+
+// RateLimitConfig sets the request limits of the routes that call the Spotify API.
+// A limit of zero requests per minute disables it.
+type RateLimitConfig struct {
+	PerIPPerMinute  int `yaml:"per_ip_per_minute"`
+	PerIPBurst      int `yaml:"per_ip_burst"`
+	GlobalPerMinute int `yaml:"global_per_minute"`
+	GlobalBurst     int `yaml:"global_burst"`
+}
+
+// LimitsConfig protects the server from oversized requests and overload.
+// A value of zero disables the corresponding limit.
+type LimitsConfig struct {
+	// MaxBodyBytes is the largest request body accepted by any endpoint
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// MaxInFlight is the number of requests served at once before new ones are shed with 503
+	MaxInFlight int `yaml:"max_in_flight"`
+	// MaxInFlightPerClient is the number of concurrent requests a single client IP may have
+	MaxInFlightPerClient int `yaml:"max_in_flight_per_client"`
+	// RetryAfter is the delay suggested to rejected clients
+	RetryAfter time.Duration `yaml:"retry_after"`
+}
+
+// SearchCacheConfig controls the short-lived cache of Spotify search results.
+// A TTL of zero disables it.
+type SearchCacheConfig struct {
+	TTL        time.Duration `yaml:"ttl"`
+	MaxEntries int           `yaml:"max_entries"`
+}
+
+// EventsConfig holds the credentials of the concert listings service.
+// The events endpoint is disabled when BandsintownAppID is empty.
+type EventsConfig struct {
+	BandsintownAppID string `yaml:"bandsintown_app_id"`
+}
+
+// LogConfig controls the application logs
+type LogConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `yaml:"level"`
+	// Format is text (human readable) or json (machine parseable)
+	Format string `yaml:"format"`
+}
+
+// Default returns a Config populated with the default values.
+// Credentials have no sensible default and are left empty.
+func Default() Config {
+	return Config{
+		Addr: ":4000",
+		Spotify: SpotifyConfig{
+			// Spotify enforces its rate limit over a rolling 30 second window
+			QuotaWindow: 30 * time.Second,
+		},
+		TLS: TLSConfig{
+			Autocert: AutocertConfig{CacheDir: "autocert-cache"},
+		},
+		RateLimit: RateLimitConfig{
+			PerIPPerMinute:  60,
+			PerIPBurst:      20,
+			GlobalPerMinute: 600,
+			GlobalBurst:     100,
+		},
+		Limits: LimitsConfig{
+			MaxBodyBytes:         1 << 20,
+			MaxInFlight:          500,
+			MaxInFlightPerClient: 20,
+			RetryAfter:           5 * time.Second,
+		},
+		SearchCache: SearchCacheConfig{
+			TTL:        time.Minute,
+			MaxEntries: 1000,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+}
+
+// Load builds the configuration in three steps:
+// the defaults are applied first, then the YAML file at path (if path is not empty),
+// and finally any environment variables that are set.
+// Secret references (file:, docker: and vault:) are then replaced by the secrets they point to,
+// and the result is validated before it is returned.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.resolveSecrets(DefaultSecretProviders()); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadFile decodes the YAML file at path on top of the current values.
+// Unknown keys are rejected so that typos don't silently fall back to defaults.
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	// An empty file is not an error, it just keeps the defaults
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides values with the matching environment variables, when set.
+// Malformed numbers are reported rather than ignored.
+func (c *Config) applyEnv() error {
+	setString(&c.Addr, "LISTEN_ADDR")
+	setString(&c.Spotify.ClientID, "SPOTIFY_CLIENT_ID")
+	setString(&c.Spotify.ClientSecret, "SPOTIFY_CLIENT_SECRET")
+	setString(&c.Spotify.DefaultMarket, "SPOTIFY_DEFAULT_MARKET")
+	setString(&c.TLS.CertFile, "TLS_CERT_FILE")
+	setString(&c.TLS.KeyFile, "TLS_KEY_FILE")
+	setString(&c.TLS.RedirectAddr, "TLS_REDIRECT_ADDR")
+	setList(&c.TLS.Autocert.Domains, "AUTOCERT_DOMAINS")
+	setString(&c.TLS.Autocert.CacheDir, "AUTOCERT_CACHE_DIR")
+	setString(&c.TLS.Autocert.Email, "AUTOCERT_EMAIL")
+	setList(&c.TrustedProxies, "TRUSTED_PROXIES")
+	setString(&c.AdminToken, "ADMIN_TOKEN")
+	setString(&c.CookieSecret, "COOKIE_SECRET")
+	setString(&c.RedisURL, "REDIS_URL")
+	setString(&c.Events.BandsintownAppID, "BANDSINTOWN_APP_ID")
+	setString(&c.Log.Level, "LOG_LEVEL")
+	setString(&c.Log.Format, "LOG_FORMAT")
+
+	ints := []struct {
+		dst *int
+		key string
+	}{
+		{&c.RateLimit.PerIPPerMinute, "RATE_LIMIT_PER_IP_PER_MINUTE"},
+		{&c.RateLimit.PerIPBurst, "RATE_LIMIT_PER_IP_BURST"},
+		{&c.RateLimit.GlobalPerMinute, "RATE_LIMIT_GLOBAL_PER_MINUTE"},
+		{&c.RateLimit.GlobalBurst, "RATE_LIMIT_GLOBAL_BURST"},
+		{&c.SearchCache.MaxEntries, "SEARCH_CACHE_MAX_ENTRIES"},
+		{&c.Spotify.DailyQuota, "SPOTIFY_DAILY_QUOTA"},
+		{&c.Spotify.WindowQuota, "SPOTIFY_WINDOW_QUOTA"},
+		{&c.Limits.MaxInFlight, "MAX_IN_FLIGHT"},
+		{&c.Limits.MaxInFlightPerClient, "MAX_IN_FLIGHT_PER_CLIENT"},
+	}
+	for _, i := range ints {
+		if err := setInt(i.dst, i.key); err != nil {
+			return err
+		}
+	}
+	if v, ok := os.LookupEnv("MAX_BODY_BYTES"); ok {
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("MAX_BODY_BYTES must be an integer: %w", err)
+		}
+		c.Limits.MaxBodyBytes = n
+	}
+	if err := setDuration(&c.Limits.RetryAfter, "RETRY_AFTER"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Spotify.QuotaWindow, "SPOTIFY_QUOTA_WINDOW"); err != nil {
+		return err
+	}
+	if err := c.setFeatures("FEATURES"); err != nil {
+		return err
+	}
+	return setDuration(&c.SearchCache.TTL, "SEARCH_CACHE_TTL")
+}
+
+// Validate checks that all required values are present.
+// Every problem is reported at once so they can be fixed in a single pass.
+func (c Config) Validate() error {
+	var problems []string
+	if c.Addr == "" {
+		problems = append(problems, "addr must not be empty")
+	}
+	if c.Spotify.ClientID == "" {
+		problems = append(problems, "spotify.client_id (SPOTIFY_CLIENT_ID) is required")
+	}
+	if c.Spotify.ClientSecret == "" {
+		problems = append(problems, "spotify.client_secret (SPOTIFY_CLIENT_SECRET) is required")
+	}
+	if c.Spotify.DefaultMarket != "" && !music.ValidMarket(c.Spotify.DefaultMarket) {
+		problems = append(problems, fmt.Sprintf("spotify.default_market: %q must be an upper-case two-letter country code such as US", c.Spotify.DefaultMarket))
+	}
+	if c.Spotify.DailyQuota < 0 || c.Spotify.WindowQuota < 0 {
+		problems = append(problems, "spotify quotas must not be negative")
+	}
+	if c.Spotify.WindowQuota > 0 && c.Spotify.QuotaWindow <= 0 {
+		problems = append(problems, "spotify.quota_window must be positive when spotify.window_quota is set")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		problems = append(problems, "tls.cert_file and tls.key_file must be set together")
+	}
+	if c.TLS.CertFile != "" && c.TLS.Autocert.Enabled() {
+		problems = append(problems, "tls.cert_file and tls.autocert.domains cannot both be set")
+	}
+	if c.TLS.Autocert.Enabled() && c.TLS.Autocert.CacheDir == "" {
+		problems = append(problems, "tls.autocert.cache_dir must not be empty when autocert is enabled")
+	}
+	for _, p := range c.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				problems = append(problems, fmt.Sprintf("trusted_proxies: %q is not an IP address or CIDR range", p))
+			}
+		}
+	}
+	if c.AdminToken != "" && len(c.AdminToken) < minSecretLength {
+		problems = append(problems, fmt.Sprintf("admin_token must be at least %d characters long", minSecretLength))
+	}
+	if c.CookieSecret != "" && len(c.CookieSecret) < minSecretLength {
+		problems = append(problems, fmt.Sprintf("cookie_secret must be at least %d characters long", minSecretLength))
+	}
+	rl := c.RateLimit
+	if rl.PerIPPerMinute < 0 || rl.PerIPBurst < 0 || rl.GlobalPerMinute < 0 || rl.GlobalBurst < 0 {
+		problems = append(problems, "rate_limit values must not be negative")
+	}
+	if c.Limits.MaxBodyBytes < 0 || c.Limits.MaxInFlight < 0 || c.Limits.MaxInFlightPerClient < 0 || c.Limits.RetryAfter < 0 {
+		problems = append(problems, "limits values must not be negative")
+	}
+	if c.SearchCache.TTL < 0 {
+		problems = append(problems, "search_cache.ttl must not be negative")
+	}
+	if c.SearchCache.TTL > 0 && c.SearchCache.MaxEntries <= 0 {
+		problems = append(problems, "search_cache.max_entries must be positive when the cache is enabled")
+	}
+	if c.RedisURL != "" {
+		if u, err := url.Parse(c.RedisURL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+			problems = append(problems, "redis_url must be a redis:// or rediss:// URL")
+		}
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, "log.level: "+err.Error())
+	}
+	if f := strings.ToLower(c.Log.Format); f != "text" && f != "json" {
+		problems = append(problems, fmt.Sprintf("log.format: %q must be text or json", c.Log.Format))
+	}
+	if _, err := features.New(c.Features); err != nil {
+		problems = append(problems, "features: "+err.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// setString overwrites dst with the environment variable key if it is set.
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+// setList overwrites dst with the comma-separated environment variable key if it is set.
+// Blank entries are dropped.
+func setList(dst *[]string, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+}
+
+// setFeatures reads feature flags from the environment variable key, e.g. "search_suggest=false,events=true".
+// Flags it doesn't mention keep the value from the file.
+func (c *Config) setFeatures(key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(item, "=")
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: %q must look like name=true or name=false", key, item)
+		}
+		if c.Features == nil {
+			c.Features = make(map[string]bool)
+		}
+		c.Features[strings.TrimSpace(name)] = on
+	}
+	return nil
+}
+
+// setInt overwrites dst with the integer environment variable key if it is set.
+func setInt(dst *int, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+// setDuration overwrites dst with the duration environment variable key (e.g. "90s") if it is set.
+func setDuration(dst *time.Duration, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("%s must be a duration such as 90s: %w", key, err)
+	}
+	*dst = d
+	return nil
+}
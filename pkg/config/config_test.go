@@ -0,0 +1,195 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// valid returns a configuration that passes Validate, for the tests to break one setting at a time
+func valid() Config {
+	cfg := Default()
+	cfg.Spotify.ClientID = "id"
+	cfg.Spotify.ClientSecret = "secret"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	secret := strings.Repeat("s", minSecretLength)
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		// problem is part of the reported problem, empty when the configuration is valid
+		problem string
+	}{
+		{"defaults with credentials", func(c *Config) {}, ""},
+		{"every optional setting", func(c *Config) {
+			c.Spotify.DefaultMarket = "US"
+			c.TLS.Autocert.Domains = []string{"example.com"}
+			c.TrustedProxies = []string{"10.0.0.1", "192.168.0.0/16", "::1"}
+			c.AdminToken, c.CookieSecret = secret, secret
+			c.RedisURL = "rediss://cache:6380/0"
+			c.Log = LogConfig{Level: "DEBUG", Format: "JSON"}
+			c.Features = map[string]bool{"events": false}
+		}, ""},
+		{"empty addr", func(c *Config) { c.Addr = "" }, "addr must not be empty"},
+		{"missing client ID", func(c *Config) { c.Spotify.ClientID = "" }, "spotify.client_id (SPOTIFY_CLIENT_ID) is required"},
+		{"missing client secret", func(c *Config) { c.Spotify.ClientSecret = "" }, "spotify.client_secret (SPOTIFY_CLIENT_SECRET) is required"},
+		{"lower-case market", func(c *Config) { c.Spotify.DefaultMarket = "us" }, `spotify.default_market: "us"`},
+		{"negative quota", func(c *Config) { c.Spotify.DailyQuota = -1 }, "spotify quotas must not be negative"},
+		{"window quota without window", func(c *Config) { c.Spotify.WindowQuota, c.Spotify.QuotaWindow = 10, 0 }, "spotify.quota_window must be positive"},
+		{"certificate without key", func(c *Config) { c.TLS.CertFile = "cert.pem" }, "tls.cert_file and tls.key_file must be set together"},
+		{"certificate and autocert", func(c *Config) {
+			c.TLS.CertFile, c.TLS.KeyFile = "cert.pem", "key.pem"
+			c.TLS.Autocert.Domains = []string{"example.com"}
+		}, "tls.cert_file and tls.autocert.domains cannot both be set"},
+		{"autocert without cache", func(c *Config) {
+			c.TLS.Autocert.Domains = []string{"example.com"}
+			c.TLS.Autocert.CacheDir = ""
+		}, "tls.autocert.cache_dir must not be empty"},
+		{"bad trusted proxy", func(c *Config) { c.TrustedProxies = []string{"proxy.local"} }, `trusted_proxies: "proxy.local"`},
+		{"short admin token", func(c *Config) { c.AdminToken = secret[1:] }, "admin_token must be at least 32 characters long"},
+		{"short cookie secret", func(c *Config) { c.CookieSecret = "secret" }, "cookie_secret must be at least 32 characters long"},
+		{"negative rate limit", func(c *Config) { c.RateLimit.GlobalBurst = -1 }, "rate_limit values must not be negative"},
+		{"negative limits", func(c *Config) { c.Limits.MaxBodyBytes = -1 }, "limits values must not be negative"},
+		{"negative cache TTL", func(c *Config) { c.SearchCache.TTL = -time.Second }, "search_cache.ttl must not be negative"},
+		{"cache without entries", func(c *Config) { c.SearchCache.MaxEntries = 0 }, "search_cache.max_entries must be positive"},
+		{"disabled cache without entries", func(c *Config) { c.SearchCache = SearchCacheConfig{} }, ""},
+		{"redis URL scheme", func(c *Config) { c.RedisURL = "http://cache:6379" }, "redis_url must be a redis:// or rediss:// URL"},
+		{"log level", func(c *Config) { c.Log.Level = "verbose" }, "log.level: unknown log level"},
+		{"log format", func(c *Config) { c.Log.Format = "xml" }, `log.format: "xml" must be text or json`},
+		{"unknown feature", func(c *Config) { c.Features = map[string]bool{"radio": true} }, `features: unknown feature flag "radio"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("Validate() = %v, want a problem containing %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := valid()
+	cfg.Addr = ""
+	cfg.Log.Format = "xml"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "addr must not be empty") || !strings.Contains(err.Error(), "log.format") {
+		t.Fatalf("Validate() = %v, want both problems", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want func(c *Config)
+		err  string
+	}{
+		{"nothing set", nil, func(c *Config) {}, ""},
+		{"strings", map[string]string{"LISTEN_ADDR": ":8080", "SPOTIFY_CLIENT_ID": "id", "LOG_FORMAT": "json"}, func(c *Config) {
+			c.Addr = ":8080"
+			c.Spotify.ClientID = "id"
+			c.Log.Format = "json"
+		}, ""},
+		{"set to empty", map[string]string{"LISTEN_ADDR": ""}, func(c *Config) { c.Addr = "" }, ""},
+		{"lists drop blank entries", map[string]string{"TRUSTED_PROXIES": " 10.0.0.1, ,10.0.0.2 "}, func(c *Config) {
+			c.TrustedProxies = []string{"10.0.0.1", "10.0.0.2"}
+		}, ""},
+		{"numbers", map[string]string{"RATE_LIMIT_PER_IP_BURST": " 5 ", "MAX_BODY_BYTES": "2048"}, func(c *Config) {
+			c.RateLimit.PerIPBurst = 5
+			c.Limits.MaxBodyBytes = 2048
+		}, ""},
+		{"durations", map[string]string{"SEARCH_CACHE_TTL": "90s", "RETRY_AFTER": "1m"}, func(c *Config) {
+			c.SearchCache.TTL = 90 * time.Second
+			c.Limits.RetryAfter = time.Minute
+		}, ""},
+		{"features", map[string]string{"FEATURES": "events=false, waveform=true"}, func(c *Config) {
+			c.Features = map[string]bool{"events": false, "waveform": true}
+		}, ""},
+		{"malformed integer", map[string]string{"SPOTIFY_DAILY_QUOTA": "lots"}, nil, "SPOTIFY_DAILY_QUOTA must be an integer"},
+		{"malformed body size", map[string]string{"MAX_BODY_BYTES": "1MB"}, nil, "MAX_BODY_BYTES must be an integer"},
+		{"malformed duration", map[string]string{"SEARCH_CACHE_TTL": "90"}, nil, "SEARCH_CACHE_TTL must be a duration"},
+		{"malformed feature", map[string]string{"FEATURES": "events"}, nil, `FEATURES: "events" must look like name=true or name=false`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			got := Default()
+			err := got.applyEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("applyEnv() = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyEnv() = %v", err)
+			}
+			want := Default()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("applyEnv() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    func(c *Config)
+		err     string
+	}{
+		{"empty file keeps the defaults", "", func(c *Config) {}, ""},
+		{"values", "addr: \":9000\"\nsearch_cache:\n  ttl: 5m\n", func(c *Config) {
+			c.Addr = ":9000"
+			c.SearchCache.TTL = 5 * time.Minute
+		}, ""},
+		{"unknown key", "adress: \":9000\"\n", nil, "field adress not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got := Default()
+			err := got.loadFile(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("loadFile() = %v, want an error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadFile() = %v", err)
+			}
+			want := Default()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("loadFile() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	cfg := Default()
+	if err := cfg.loadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadFile() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,151 @@
+// This file will resolve the secrets that are kept outside the configuration: in files, Docker secrets or Vault.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SecretProvider fetches secrets from a store, given the part of a reference after its scheme.
+// References look like file:/run/secrets/spotify, docker:spotify or vault:secret/data/smartmusic#client_secret.
+type SecretProvider interface {
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// FileSecrets reads secrets from files, one secret per file.
+// Relative names are looked up in Dir, which is how Docker and Kubernetes mount secrets.
+type FileSecrets struct {
+	Dir string
+}
+
+// Secret returns the content of the file, without the trailing newline editors like to add
+func (f FileSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	path := ref
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(f.Dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// VaultSecrets reads secrets from the KV secrets engine of HashiCorp Vault.
+// A reference is the API path of the secret and the key within it, e.g. secret/data/smartmusic#client_secret.
+type VaultSecrets struct {
+	// Addr is the address of the Vault server, e.g. https://vault.example.com:8200
+	Addr string
+	// Token authenticates the requests
+	Token  string
+	Client *http.Client
+}
+
+// NewVaultSecrets creates a Vault provider from the standard VAULT_ADDR and VAULT_TOKEN environment variables
+func NewVaultSecrets() *VaultSecrets {
+	return &VaultSecrets{
+		Addr:   os.Getenv("VAULT_ADDR"),
+		Token:  os.Getenv("VAULT_TOKEN"),
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Secret reads the secret at the path of ref and returns its key
+func (v *VaultSecrets) Secret(ctx context.Context, ref string) (string, error) {
+	if v.Addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault reference must look like secret/data/name#key")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(v.Addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault answered %s for %s", resp.Status, path)
+	}
+
+	// Version 2 of the KV engine nests the values in data.data, version 1 has them in data
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding vault response: %w", err)
+	}
+	values := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		values = nil
+		if err := json.Unmarshal(nested, &values); err != nil {
+			return "", fmt.Errorf("decoding vault response: %w", err)
+		}
+	}
+	raw, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no key %q", path, key)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("vault secret %s key %q is not a string", path, key)
+	}
+	return value, nil
+}
+
+// DefaultSecretProviders are the providers used by Load, keyed by reference scheme
+func DefaultSecretProviders() map[string]SecretProvider {
+	return map[string]SecretProvider{
+		"file":   FileSecrets{},
+		"docker": FileSecrets{Dir: "/run/secrets"},
+		"vault":  NewVaultSecrets(),
+	}
+}
+
+// secretsTimeout bounds the time spent fetching every secret at startup
+const secretsTimeout = 30 * time.Second
+
+// resolveSecrets replaces the settings holding a secret reference with the secret it points to.
+// Settings with a scheme no provider handles are kept as they are.
+func (c *Config) resolveSecrets(providers map[string]SecretProvider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	secrets := []struct {
+		dst  *string
+		name string
+	}{
+		{&c.Spotify.ClientID, "spotify.client_id"},
+		{&c.Spotify.ClientSecret, "spotify.client_secret"},
+		{&c.AdminToken, "admin_token"},
+		{&c.CookieSecret, "cookie_secret"},
+		{&c.RedisURL, "redis_url"},
+		{&c.Events.BandsintownAppID, "events.bandsintown_app_id"},
+	}
+	for _, s := range secrets {
+		scheme, ref, ok := strings.Cut(*s.dst, ":")
+		provider, known := providers[scheme]
+		if !ok || !known {
+			continue
+		}
+		value, err := provider.Secret(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("%s: reading secret %s: %w", s.name, *s.dst, err)
+		}
+		*s.dst = value
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "client_secret"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "admin_token"), []byte("from-docker\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/smartmusic":
+			w.Write([]byte(`{"data":{"data":{"client_id":"from-vault-v2","count":3}}}`))
+		case "/v1/kv/smartmusic":
+			w.Write([]byte(`{"data":{"client_id":"from-vault-v1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer vault.Close()
+
+	providers := map[string]SecretProvider{
+		"file":   FileSecrets{},
+		"docker": FileSecrets{Dir: dir},
+		"vault":  &VaultSecrets{Addr: vault.URL, Token: "token", Client: vault.Client()},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		err   string
+	}{
+		{"plain value", "plain", "plain", ""},
+		{"file", "file:" + filepath.Join(dir, "client_secret"), "from-file", ""},
+		{"docker", "docker:admin_token", "from-docker", ""},
+		{"vault KV version 2", "vault:secret/data/smartmusic#client_id", "from-vault-v2", ""},
+		{"vault KV version 1", "vault:kv/smartmusic#client_id", "from-vault-v1", ""},
+		{"unknown scheme is kept", "redis://cache:6379/0", "redis://cache:6379/0", ""},
+		{"missing file", "file:" + filepath.Join(dir, "missing"), "", "no such file or directory"},
+		{"missing docker secret", "docker:missing", "", "no such file or directory"},
+		{"vault reference without key", "vault:secret/data/smartmusic", "", "vault reference must look like secret/data/name#key"},
+		{"vault missing key", "vault:secret/data/smartmusic#client_secret", "", `has no key "client_secret"`},
+		{"vault value not a string", "vault:secret/data/smartmusic#count", "", `key "count" is not a string`},
+		{"vault missing secret", "vault:secret/data/other#client_id", "", "vault answered 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Spotify.ClientSecret = tt.value
+			err := cfg.resolveSecrets(providers)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) || !strings.HasPrefix(err.Error(), "spotify.client_secret: ") {
+					t.Fatalf("resolveSecrets() = %v, want an error about spotify.client_secret containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveSecrets() = %v", err)
+			}
+			if cfg.Spotify.ClientSecret != tt.want {
+				t.Errorf("resolveSecrets() set %q, want %q", cfg.Spotify.ClientSecret, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultSecretsWithoutAddr(t *testing.T) {
+	_, err := (&VaultSecrets{}).Secret(context.Background(), "secret/data/smartmusic#client_id")
+	if err == nil || err.Error() != "VAULT_ADDR is not set" {
+		t.Errorf("Secret() = %v, want VAULT_ADDR is not set", err)
+	}
+}
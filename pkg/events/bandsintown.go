@@ -0,0 +1,182 @@
+// This file will contain the client of the Bandsintown API, which lists the upcoming events of an artist.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/cache"
+)
+
+const (
+	// bandsintownURL is the base URL of the Bandsintown API
+	bandsintownURL = "https://rest.bandsintown.com"
+	// maxResponseBytes bounds the events read for an artist, even busy touring artists list far less
+	maxResponseBytes = 2 << 20
+)
+
+// artistEscaper encodes the characters Bandsintown wants escaped twice in artist names, e.g. AC/DC is sent as AC%252FDC
+var artistEscaper = strings.NewReplacer("/", "%2F", "?", "%3F", "*", "%2A", `"`, "%22")
+
+// Bandsintown lists events from Bandsintown.
+// See https://help.artists.bandsintown.com/en/articles/9186477-api-documentation
+type Bandsintown struct {
+	appID  string
+	client *http.Client
+	// cache keeps the events of each artist when EnableCache was called, nil otherwise
+	cache cache.Store
+}
+
+// NewBandsintown creates a Bandsintown client identified by appID.
+// API calls go through base, which may be nil to use http.DefaultTransport.
+func NewBandsintown(appID string, base http.RoundTripper) *Bandsintown {
+	return &Bandsintown{appID: appID, client: &http.Client{Transport: base, Timeout: 10 * time.Second}}
+}
+
+// EnableCache keeps the events of each artist in store.
+// Listings change a few times a day at most, so a TTL of an hour or so is plenty.
+func (b *Bandsintown) EnableCache(store cache.Store) {
+	b.cache = store
+}
+
+// bandsintownEvent is an event as returned by the Bandsintown API
+type bandsintownEvent struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Datetime string `json:"datetime"`
+	Venue    struct {
+		Name      string `json:"name"`
+		City      string `json:"city"`
+		Region    string `json:"region"`
+		Country   string `json:"country"`
+		Latitude  string `json:"latitude"`
+		Longitude string `json:"longitude"`
+	} `json:"venue"`
+	Lineup []string `json:"lineup"`
+	Offers []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"offers"`
+}
+
+// ArtistEvents returns the upcoming events of artist
+func (b *Bandsintown) ArtistEvents(ctx context.Context, artist string) ([]Event, error) {
+	key := strings.ToLower(strings.TrimSpace(artist))
+	if cached, ok := b.cachedEvents(ctx, key, artist); ok {
+		return cached, nil
+	}
+
+	name := url.PathEscape(artistEscaper.Replace(artist))
+	endpoint := fmt.Sprintf("%s/artists/%s/events?%s", bandsintownURL, name, url.Values{"app_id": {b.appID}}.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(io.LimitReader(resp.Body, maxResponseBytes+1)); err != nil {
+		return nil, err
+	}
+	if body.Len() > maxResponseBytes {
+		return nil, fmt.Errorf("bandsintown: response is larger than %d bytes", maxResponseBytes)
+	}
+	// Unknown artists come back as 404, or as an object with a warning instead of a list
+	if resp.StatusCode == http.StatusNotFound {
+		return []Event{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bandsintown: unexpected status %s", resp.Status)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(body.Bytes()), []byte("[")) {
+		return []Event{}, nil
+	}
+
+	var found []bandsintownEvent
+	if err := json.Unmarshal(body.Bytes(), &found); err != nil {
+		return nil, fmt.Errorf("bandsintown: decoding events: %w", err)
+	}
+	list := make([]Event, 0, len(found))
+	for _, e := range found {
+		list = append(list, toEvent(artist, e))
+	}
+	b.cacheEvents(ctx, key, list)
+	return list, nil
+}
+
+// toEvent converts a Bandsintown event into an Event
+func toEvent(artist string, e bandsintownEvent) Event {
+	event := Event{
+		ID:     e.ID,
+		Artist: artist,
+		Title:  e.Title,
+		Starts: e.Datetime,
+		Venue: Venue{
+			Name:    e.Venue.Name,
+			City:    e.Venue.City,
+			Region:  e.Venue.Region,
+			Country: e.Venue.Country,
+		},
+		Lineup:   e.Lineup,
+		URL:      e.URL,
+		Provider: "bandsintown",
+	}
+	if event.Lineup == nil {
+		event.Lineup = []string{}
+	}
+	// Coordinates are sent as strings, and left out for some venues
+	event.Venue.Latitude, _ = strconv.ParseFloat(e.Venue.Latitude, 64)
+	event.Venue.Longitude, _ = strconv.ParseFloat(e.Venue.Longitude, 64)
+	for _, o := range e.Offers {
+		if o.Type == "Tickets" {
+			event.TicketURL = o.URL
+			break
+		}
+	}
+	return event
+}
+
+// cachedEvents returns the cached events of an artist, treating cache failures as misses.
+// The key ignores case, so the events are given the name of the artist as the caller wrote it.
+func (b *Bandsintown) cachedEvents(ctx context.Context, key, artist string) ([]Event, bool) {
+	if b.cache == nil {
+		return nil, false
+	}
+	data, ok, err := b.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var list []Event
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, false
+	}
+	for i := range list {
+		list[i].Artist = artist
+	}
+	return list, true
+}
+
+// cacheEvents stores the events of an artist, ignoring cache failures
+func (b *Bandsintown) cacheEvents(ctx context.Context, key string, list []Event) {
+	if b.cache == nil {
+		return
+	}
+	if data, err := json.Marshal(list); err == nil {
+		_ = b.cache.Set(ctx, key, data)
+	}
+}
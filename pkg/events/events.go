@@ -0,0 +1,70 @@
+// This file will describe concerts and live events, independently of the service listing them.
+
+package events
+
+import (
+	"context"
+	"math"
+)
+
+// Event is a concert or festival date of an artist
+type Event struct {
+	ID string `json:"id"`
+	// Artist is the artist the events were requested for
+	Artist string `json:"artist"`
+	// Title is the name of the event, often empty for a regular concert
+	Title string `json:"title,omitempty"`
+	// Starts is the local date and time at the venue, e.g. "2024-07-12T20:00:00"
+	Starts string `json:"starts"`
+	Venue  Venue  `json:"venue"`
+	// Lineup lists every artist on the bill
+	Lineup    []string `json:"lineup"`
+	URL       string   `json:"url"`
+	TicketURL string   `json:"ticket_url,omitempty"`
+	Provider  string   `json:"provider"`
+}
+
+// Venue is where an event takes place
+type Venue struct {
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Provider lists the upcoming events of artists, e.g. Bandsintown
+type Provider interface {
+	// ArtistEvents returns the upcoming events of the artist with the given name, soonest first.
+	// An artist the provider doesn't know has no events.
+	ArtistEvents(ctx context.Context, artist string) ([]Event, error)
+}
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371
+
+// Near keeps the events whose venue is within radiusKm of the given coordinates.
+// Venues without coordinates are dropped, as their distance is unknown.
+func Near(events []Event, latitude, longitude, radiusKm float64) []Event {
+	near := []Event{}
+	for _, e := range events {
+		if e.Venue.Latitude == 0 && e.Venue.Longitude == 0 {
+			continue
+		}
+		if Distance(latitude, longitude, e.Venue.Latitude, e.Venue.Longitude) <= radiusKm {
+			near = append(near, e)
+		}
+	}
+	return near
+}
+
+// Distance returns the great-circle distance in kilometres between two points, using the haversine formula
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
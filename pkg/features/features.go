@@ -0,0 +1,78 @@
+// This file will contain the feature flags turning optional features on and off without a new release.
+
+package features
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Flag is an optional feature that can be switched off (or on) in the configuration
+type Flag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Known lists every flag. Flags are removed once their feature is permanent.
+var Known = []Flag{
+	{Name: "search_suggest", Description: "typeahead suggestions (/api/search/suggest)", Default: true},
+	{Name: "search_all", Description: "grouped search of tracks, artists, albums and playlists (/api/search/all)", Default: true},
+	{Name: "spelling_correction", Description: "retry searches that find nothing with misspelled words corrected", Default: true},
+	{Name: "waveform", Description: "waveforms drawn from track previews (/api/tracks/{id}/waveform)", Default: true},
+	{Name: "artist_bio", Description: "Wikipedia biographies on artist details (/api/artists/{id})", Default: true},
+	{Name: "events", Description: "concert listings (/api/events), when a provider is configured", Default: true},
+}
+
+// Flags holds the state of every known flag.
+// A nil *Flags has every flag at its default.
+type Flags struct {
+	enabled map[string]bool
+}
+
+// New applies overrides, keyed by flag name, on top of the defaults.
+// Unknown names are rejected so that a typo doesn't silently leave a feature on.
+func New(overrides map[string]bool) (*Flags, error) {
+	f := &Flags{enabled: make(map[string]bool, len(Known))}
+	for _, flag := range Known {
+		f.enabled[flag.Name] = flag.Default
+	}
+	for name, on := range overrides {
+		if _, ok := f.enabled[name]; !ok {
+			return nil, fmt.Errorf("unknown feature flag %q", name)
+		}
+		f.enabled[name] = on
+	}
+	return f, nil
+}
+
+// Enabled reports whether the feature called name is on.
+// Unknown names are off, so a flag can't be checked before it is declared in Known.
+func (f *Flags) Enabled(name string) bool {
+	if f == nil {
+		for _, flag := range Known {
+			if flag.Name == name {
+				return flag.Default
+			}
+		}
+		return false
+	}
+	return f.enabled[name]
+}
+
+// State returns every flag with whether it is on, sorted by name
+func (f *Flags) State() []FlagState {
+	states := make([]FlagState, 0, len(Known))
+	for _, flag := range Known {
+		states = append(states, FlagState{Name: flag.Name, Description: flag.Description, Enabled: f.Enabled(flag.Name)})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// FlagState is a flag and whether it is on, as shown on the admin dashboard
+type FlagState struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
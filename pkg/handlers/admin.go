@@ -0,0 +1,147 @@
+// This file will contain the admin-only handlers that give operators visibility into the running instance.
+
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/features"
+	"Smart-Music-Go/pkg/quota"
+)
+
+// providerCheckTimeout bounds how long the admin status waits for an upstream health check
+const providerCheckTimeout = 5 * time.Second
+
+// AdminStatus is the JSON document returned by /api/admin/status
+type AdminStatus struct {
+	Uptime     string               `json:"uptime"`
+	GoVersion  string               `json:"go_version"`
+	Goroutines int                  `json:"goroutines"`
+	MemoryMB   float64              `json:"memory_mb"`
+	Providers  []ProviderHealth     `json:"providers"`
+	Quotas     []quota.Usage        `json:"quotas"`
+	ErrorCount int                  `json:"recent_error_count"`
+	Features   []features.FlagState `json:"features"`
+}
+
+// ProviderHealth reports whether an upstream API can currently be reached
+type ProviderHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RequireAdmin wraps an admin handler so it is only served to callers presenting the admin token,
+// either as a bearer token or as the password of HTTP basic auth (handy in a browser).
+// When no token is configured the admin endpoints don't exist.
+func (app *Application) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var given string
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			given = strings.TrimPrefix(auth, "Bearer ")
+		} else if _, password, ok := r.BasicAuth(); ok {
+			given = password
+		}
+
+		if subtle.ConstantTimeCompare([]byte(given), []byte(app.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Smart-Music-Go admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// AdminStatusJSON responds with the current status of the instance
+func (app *Application) AdminStatusJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, app.adminStatus(r.Context()))
+}
+
+// AdminErrorsJSON responds with the most recent server errors, newest first
+func (app *Application) AdminErrorsJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, app.recentErrors())
+}
+
+// AdminQuotasJSON responds with the outbound request counts and remaining quota of each provider
+func (app *Application) AdminQuotasJSON(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, app.quotaUsage())
+}
+
+// AdminDashboard renders the status and recent errors as a simple HTML page
+func (app *Application) AdminDashboard(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Status AdminStatus
+		Errors []ErrorEntry
+	}{
+		Status: app.adminStatus(r.Context()),
+		Errors: app.recentErrors(),
+	}
+	app.render(w, r, http.StatusOK, "admin.html", "Admin", data)
+}
+
+// adminStatus gathers runtime statistics and checks the upstream providers
+func (app *Application) adminStatus(ctx context.Context) AdminStatus {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return AdminStatus{
+		Uptime:     time.Since(app.Started).Round(time.Second).String(),
+		GoVersion:  runtime.Version(),
+		Goroutines: runtime.NumGoroutine(),
+		MemoryMB:   float64(mem.Alloc) / (1 << 20),
+		Providers:  []ProviderHealth{app.spotifyHealth(ctx)},
+		Quotas:     app.quotaUsage(),
+		ErrorCount: len(app.recentErrors()),
+		Features:   app.Features.State(),
+	}
+}
+
+// spotifyHealth checks that Spotify accepts our credentials
+func (app *Application) spotifyHealth(ctx context.Context) ProviderHealth {
+	ctx, cancel := context.WithTimeout(ctx, providerCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := app.Spotify.CheckCredentials(ctx)
+	health := ProviderHealth{Name: "spotify", Healthy: err == nil, Latency: time.Since(start).Round(time.Millisecond).String()}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health
+}
+
+// quotaUsage returns the usage of every provider, or an empty list when quotas are not tracked
+func (app *Application) quotaUsage() []quota.Usage {
+	if app.Quotas == nil {
+		return []quota.Usage{}
+	}
+	return app.Quotas.Usage()
+}
+
+// recentErrors returns the recorded errors, or an empty list when errors are not tracked
+func (app *Application) recentErrors() []ErrorEntry {
+	if app.Errors == nil {
+		return []ErrorEntry{}
+	}
+	return app.Errors.Recent()
+}
+
+// writeJSON encodes v as the JSON response body
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "An error occurred while encoding the response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,67 @@
+// This file will contain the JSON handlers giving details about albums.
+
+package handlers
+
+import (
+	"net/http"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// Album responds with an album, its artwork, release date, copyright notices and complete tracklist.
+// The optional market parameter relinks the tracks unavailable in that country.
+// The track filters (see parseTrackFilter) apply to the tracklist, which keeps its disc and track numbers.
+func (app *Application) Album(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !music.ValidSpotifyID(id) {
+		http.Error(w, "Invalid album ID", http.StatusBadRequest)
+		return
+	}
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseTrackFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	album, err := app.Spotify.Album(r.Context(), id, market)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while fetching the album", err)
+		return
+	}
+	album.Tracks, err = app.filterAlbumTracks(r, album.Tracks, filter)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while analysing the tracks", err)
+		return
+	}
+	app.cacheControl(w)
+	writeJSON(w, album)
+}
+
+// filterAlbumTracks keeps the tracks of a tracklist matching the filter, see filterTracks
+func (app *Application) filterAlbumTracks(r *http.Request, tracks []music.AlbumTrack, f trackFilter) ([]music.AlbumTrack, error) {
+	plain := make([]music.Track, len(tracks))
+	for i, t := range tracks {
+		plain[i] = t.Track
+	}
+	kept, err := app.filterTracks(r, plain, f)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(kept))
+	for _, t := range kept {
+		ids[t.ID] = true
+	}
+	filtered := []music.AlbumTrack{}
+	for _, t := range tracks {
+		if ids[t.ID] {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Smart-Music-Go/pkg/music"
+	"Smart-Music-Go/pkg/spotify"
+
+	spotifyapi "github.com/zmb3/spotify/v2"
+)
+
+// testAlbumID and testArtistID are well-formed album and artist IDs
+const (
+	testAlbumID  = "4m2880jivSbbyEGAKfITCa"
+	testArtistID = "4tZwfgrHOc3mvqYlEYSvVi"
+)
+
+func TestCatalogHandlersRejectInvalidIDs(t *testing.T) {
+	app := &Application{Spotify: uncalledSpotify(t)}
+
+	handlers := map[string]struct {
+		handler http.HandlerFunc
+		valid   string
+	}{
+		"album":  {app.Album, testAlbumID},
+		"artist": {app.Artist, testArtistID},
+	}
+	for name, h := range handlers {
+		for _, id := range invalidIDs(h.valid) {
+			r := httptest.NewRequest(http.MethodGet, "/api/"+name+"s/x", nil)
+			r.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			h.handler(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s %q: status %d, want 400", name, id, w.Code)
+			}
+		}
+	}
+}
+
+func TestAlbumFilters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/albums/"+testAlbumID {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,"name":"Discovery","album_type":"album","artists":[{"name":"Daft Punk"}],"tracks":{"items":[
+			{"id":"a","name":"One More Time","explicit":false,"disc_number":1,"track_number":1},
+			{"id":"b","name":"Aerodynamic","explicit":true,"disc_number":1,"track_number":2},
+			{"id":"c","name":"Digital Love","explicit":false,"disc_number":1,"track_number":3}]}}`, testAlbumID)
+	}))
+	defer srv.Close()
+	app := &Application{Spotify: &spotify.SpotifyClient{Client: spotifyapi.New(srv.Client(), spotifyapi.WithBaseURL(srv.URL+"/"))}}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3}},
+		{"?explicit=false", []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/albums/"+testAlbumID+tt.query, nil)
+			r.SetPathValue("id", testAlbumID)
+			w := httptest.NewRecorder()
+			app.Album(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+
+			var album music.AlbumDetails
+			if err := json.Unmarshal(w.Body.Bytes(), &album); err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, track := range album.Tracks {
+				got = append(got, track.TrackNumber)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("track numbers %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
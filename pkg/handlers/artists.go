@@ -0,0 +1,52 @@
+// This file will contain the JSON handlers giving details about artists.
+
+package handlers
+
+import (
+	"net/http"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// Artist responds with an artist, their top tracks, discography and related artists, and a short biography.
+// The optional market parameter picks the country whose top tracks and releases are listed.
+// The biography is best effort: when it can't be fetched, or the feature is off, it is null.
+// The track filters (see parseTrackFilter) apply to the top tracks.
+func (app *Application) Artist(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !music.ValidSpotifyID(id) {
+		http.Error(w, "Invalid artist ID", http.StatusBadRequest)
+		return
+	}
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseTrackFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	artist, err := app.Spotify.Artist(r.Context(), id, market)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while fetching the artist", err)
+		return
+	}
+	artist.TopTracks, err = app.filterTracks(r, artist.TopTracks, filter)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while analysing the tracks", err)
+		return
+	}
+	if app.Bios != nil && app.Features.Enabled("artist_bio") {
+		bio, err := app.Bios.ArtistBio(r.Context(), artist.Name)
+		if err != nil {
+			app.logger().Warn("fetching artist bio failed", "artist", artist.Name, "err", err)
+		}
+		artist.Bio = bio
+	}
+
+	app.cacheControl(w)
+	writeJSON(w, artist)
+}
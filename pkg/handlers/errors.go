@@ -0,0 +1,86 @@
+// This file will keep track of recent server errors so operators can inspect them without shell access.
+
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrorEntry is a single server error as shown on the admin endpoints
+type ErrorEntry struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Message string    `json:"message"`
+	Error   string    `json:"error"`
+}
+
+// ErrorLog is a fixed-size, concurrency-safe ring buffer of the most recent errors
+type ErrorLog struct {
+	mu      sync.Mutex
+	entries []ErrorEntry
+	next    int
+	full    bool
+}
+
+// NewErrorLog returns an ErrorLog keeping the last size errors
+func NewErrorLog(size int) *ErrorLog {
+	if size <= 0 {
+		size = 1
+	}
+	return &ErrorLog{entries: make([]ErrorEntry, size)}
+}
+
+// Add records an error, overwriting the oldest one when the log is full
+func (l *ErrorLog) Add(e ErrorEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns the recorded errors, newest first
+func (l *ErrorLog) Recent() []ErrorEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	recent := make([]ErrorEntry, 0, n)
+	for i := 1; i <= n; i++ {
+		recent = append(recent, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return recent
+}
+
+// serverError logs and records err, then responds with a generic 500 message,
+// so internal details are never shown to the user.
+func (app *Application) serverError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	app.logger().Error(message, "method", r.Method, "path", r.URL.Path, "err", err)
+	if app.Errors != nil {
+		app.Errors.Add(ErrorEntry{
+			Time:    time.Now(),
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Message: message,
+			Error:   err.Error(),
+		})
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// unavailable responds with 503 and tells the client when to retry, rounded up to whole seconds
+func unavailable(w http.ResponseWriter, message string, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, message, http.StatusServiceUnavailable)
+}
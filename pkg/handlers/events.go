@@ -0,0 +1,82 @@
+// This file will contain the JSON handlers listing concerts and live events.
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Smart-Music-Go/pkg/events"
+)
+
+// defaultEventRadiusKm is how far from the near parameter events are looked for when no radius is given
+const defaultEventRadiusKm = 100
+
+// EventsResponse is the JSON body returned by ArtistEvents
+type EventsResponse struct {
+	Artist string         `json:"artist"`
+	Events []events.Event `json:"events"`
+}
+
+// ArtistEvents responds with the upcoming events of the artist named by the artist query parameter.
+// With near=latitude,longitude only the events within radius_km (default 100) of that point are kept.
+// The endpoint doesn't exist when no events provider is configured.
+func (app *Application) ArtistEvents(w http.ResponseWriter, r *http.Request) {
+	if app.Events == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	artist := strings.TrimSpace(q.Get("artist"))
+	if artist == "" {
+		http.Error(w, "artist is required", http.StatusBadRequest)
+		return
+	}
+	near, latitude, longitude, radius, err := locationParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := app.Events.ArtistEvents(r.Context(), artist)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while fetching events", err)
+		return
+	}
+	if near {
+		list = events.Near(list, latitude, longitude, radius)
+	}
+	writeJSON(w, EventsResponse{Artist: artist, Events: list})
+}
+
+// locationParams reads the optional near (latitude,longitude) and radius_km query parameters
+func locationParams(r *http.Request) (near bool, latitude, longitude, radius float64, err error) {
+	q := r.URL.Query()
+	v := q.Get("near")
+	if v == "" {
+		return false, 0, 0, 0, nil
+	}
+
+	invalid := errors.New("near must be a latitude and a longitude separated by a comma, e.g. 51.5,-0.12")
+	lat, lon, ok := strings.Cut(v, ",")
+	if !ok {
+		return false, 0, 0, 0, invalid
+	}
+	if latitude, err = strconv.ParseFloat(strings.TrimSpace(lat), 64); err != nil || latitude < -90 || latitude > 90 {
+		return false, 0, 0, 0, invalid
+	}
+	if longitude, err = strconv.ParseFloat(strings.TrimSpace(lon), 64); err != nil || longitude < -180 || longitude > 180 {
+		return false, 0, 0, 0, invalid
+	}
+
+	radius = defaultEventRadiusKm
+	if v := q.Get("radius_km"); v != "" {
+		if radius, err = strconv.ParseFloat(v, 64); err != nil || radius <= 0 {
+			return false, 0, 0, 0, errors.New("radius_km must be a positive number of kilometres")
+		}
+	}
+	return true, latitude, longitude, radius, nil
+}
@@ -0,0 +1,100 @@
+// This file will contain the filters applied to lists of tracks, e.g. to hide explicit tracks or keep the fast ones.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// trackFilter holds the constraints on the tracks of a list, zero values are unset
+type trackFilter struct {
+	minTempo    float64
+	minEnergy   float64
+	maxDuration time.Duration
+	// noExplicit drops the tracks with explicit lyrics
+	noExplicit bool
+}
+
+// parseTrackFilter reads the min_tempo (BPM), min_energy (0-1), max_duration (seconds)
+// and explicit (false to hide explicit tracks) query parameters
+func parseTrackFilter(r *http.Request) (trackFilter, error) {
+	var f trackFilter
+	q := r.URL.Query()
+	if v := q.Get("explicit"); v != "" {
+		allowed, err := strconv.ParseBool(v)
+		if err != nil {
+			return trackFilter{}, fmt.Errorf("explicit must be true or false")
+		}
+		f.noExplicit = !allowed
+	}
+	if v := q.Get("min_tempo"); v != "" {
+		tempo, err := strconv.ParseFloat(v, 64)
+		if err != nil || tempo <= 0 {
+			return trackFilter{}, fmt.Errorf("min_tempo must be a positive number of beats per minute")
+		}
+		f.minTempo = tempo
+	}
+	if v := q.Get("min_energy"); v != "" {
+		energy, err := strconv.ParseFloat(v, 64)
+		if err != nil || energy < 0 || energy > 1 {
+			return trackFilter{}, fmt.Errorf("min_energy must be a number between 0 and 1")
+		}
+		f.minEnergy = energy
+	}
+	if v := q.Get("max_duration"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return trackFilter{}, fmt.Errorf("max_duration must be a positive number of seconds")
+		}
+		f.maxDuration = time.Duration(seconds) * time.Second
+	}
+	return f, nil
+}
+
+// needsAnalysis reports whether the filter uses audio features, which take an extra API call
+func (f trackFilter) needsAnalysis() bool {
+	return f.minTempo > 0 || f.minEnergy > 0
+}
+
+// filterTracks keeps the tracks matching the filter.
+// The audio features are fetched in a single batch when needed, and tracks without any are dropped.
+func (app *Application) filterTracks(r *http.Request, tracks []music.Track, f trackFilter) ([]music.Track, error) {
+	if f == (trackFilter{}) || len(tracks) == 0 {
+		return tracks, nil
+	}
+
+	var analyses map[string]music.Analysis
+	if f.needsAnalysis() {
+		ids := make([]string, len(tracks))
+		for i, t := range tracks {
+			ids[i] = t.ID
+		}
+		var err error
+		if analyses, err = app.Spotify.TrackAnalyses(r.Context(), ids); err != nil {
+			return nil, err
+		}
+	}
+
+	kept := []music.Track{}
+	for _, t := range tracks {
+		if f.noExplicit && t.Explicit {
+			continue
+		}
+		if f.maxDuration > 0 && time.Duration(t.DurationMS)*time.Millisecond > f.maxDuration {
+			continue
+		}
+		if f.needsAnalysis() {
+			a, ok := analyses[t.ID]
+			if !ok || a.Tempo < f.minTempo || a.Energy < f.minEnergy {
+				continue
+			}
+		}
+		kept = append(kept, t)
+	}
+	return kept, nil
+}
@@ -0,0 +1,95 @@
+// This file will contain the flash messages: one-time notices carried in a signed cookie to the page shown after a redirect.
+
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/middleware"
+)
+
+// flashCookie carries the message to the next page
+const flashCookie = "flash"
+
+// flashLifetime is how long a flash waits to be shown, long enough to follow a redirect
+const flashLifetime = time.Minute
+
+// Flash is a message shown once, on the next HTML page the visitor sees
+type Flash struct {
+	// Kind is info or error, and styles the message
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// flashRedirect shows message on the page at url, then redirects there.
+// This lets a form that fails go back to a page (post/redirect/get) instead of answering with a bare error.
+func (app *Application) flashRedirect(w http.ResponseWriter, r *http.Request, url, kind, message string) {
+	app.setFlash(w, r, Flash{Kind: kind, Message: message})
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// setFlash stores f in a cookie signed with the cookie key, so visitors can't forge messages.
+// Without a key the flash is dropped.
+func (app *Application) setFlash(w http.ResponseWriter, r *http.Request, f Flash) {
+	if len(app.CookieKey) == 0 {
+		return
+	}
+	payload, err := json.Marshal(f)
+	if err != nil {
+		return
+	}
+	value := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookie,
+		Value:    value + "." + app.sign(value),
+		Path:     "/",
+		MaxAge:   int(flashLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   middleware.Scheme(r) == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// popFlash returns the flash waiting for this request and deletes it, so it is shown only once.
+// A cookie whose signature doesn't match is deleted and ignored.
+func (app *Application) popFlash(w http.ResponseWriter, r *http.Request) *Flash {
+	c, err := r.Cookie(flashCookie)
+	if err != nil {
+		return nil
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   middleware.Scheme(r) == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	value, signature, ok := strings.Cut(c.Value, ".")
+	if !ok || len(app.CookieKey) == 0 || !hmac.Equal([]byte(signature), []byte(app.sign(value))) {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var f Flash
+	if err := json.Unmarshal(payload, &f); err != nil {
+		return nil
+	}
+	return &f
+}
+
+// sign returns the HMAC-SHA256 of value under the cookie key
+func (app *Application) sign(value string) string {
+	mac := hmac.New(sha256.New, app.CookieKey)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testCookieKey is a cookie key of the minimum length
+var testCookieKey = []byte(strings.Repeat("k", 32))
+
+// flashCookieValue sets a flash with app and returns the value of the cookie it wrote
+func flashCookieValue(t *testing.T, app *Application, f Flash) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	app.setFlash(w, httptest.NewRequest(http.MethodPost, "/theme", nil), f)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == flashCookie {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func TestFlash(t *testing.T) {
+	app := &Application{CookieKey: testCookieKey}
+	sent := Flash{Kind: "error", Message: "market must be a two-letter country code such as US or GB"}
+	value := flashCookieValue(t, app, sent)
+	payload, signature, _ := strings.Cut(value, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"kind":"info","message":"forged"}`))
+
+	tests := []struct {
+		name  string
+		app   *Application
+		value string
+		want  *Flash
+	}{
+		{"round trip", app, value, &sent},
+		{"tampered payload", app, forged + "." + signature, nil},
+		{"tampered signature", app, payload + "." + app.sign(forged), nil},
+		{"missing signature", app, payload, nil},
+		{"other key", &Application{CookieKey: []byte(strings.Repeat("o", 32))}, value, nil},
+		{"no key", &Application{}, value, nil},
+		{"signed garbage", app, "garbage." + app.sign("garbage"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(&http.Cookie{Name: flashCookie, Value: tt.value})
+			w := httptest.NewRecorder()
+			got := tt.app.popFlash(w, r)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("popFlash() = %v, want %v", got, tt.want)
+			}
+
+			// The cookie is deleted whether it was valid or not, so a bad one isn't sent again
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != flashCookie || cookies[0].MaxAge != -1 {
+				t.Errorf("cookies = %v, want the flash cookie deleted", cookies)
+			}
+		})
+	}
+}
+
+func TestFlashWithoutKeyOrCookie(t *testing.T) {
+	if value := flashCookieValue(t, &Application{}, Flash{Kind: "info", Message: "saved"}); value != "" {
+		t.Errorf("setFlash() without a key wrote %q, want no cookie", value)
+	}
+
+	w := httptest.NewRecorder()
+	if f := (&Application{CookieKey: testCookieKey}).popFlash(w, httptest.NewRequest(http.MethodGet, "/", nil)); f != nil || len(w.Result().Cookies()) != 0 {
+		t.Errorf("popFlash() without a cookie = %v, %v, want nothing", f, w.Result().Cookies())
+	}
+}
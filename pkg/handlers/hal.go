@@ -0,0 +1,92 @@
+// This file will contain the HAL representation of paged responses, for clients asking for application/hal+json.
+
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// halMediaType is the media type of HAL documents, see https://datatracker.ietf.org/doc/html/draft-kelly-json-hal
+const halMediaType = "application/hal+json"
+
+// halLink is a link of a HAL document, relative to the server
+type halLink struct {
+	Href string `json:"href"`
+}
+
+// halTrack is a track embedded in a HAL document, with links to the resources about it
+type halTrack struct {
+	music.Track
+	Links map[string]halLink `json:"_links"`
+}
+
+// wantsHAL reports whether the client listed application/hal+json in its Accept header
+func wantsHAL(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == halMediaType && params["q"] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTracks responds with a page of tracks: plain as it is, or as a HAL document with the tracks embedded
+// and links to the other pages when the client asked for HAL.
+// fields are the other properties of the HAL document, e.g. the playlist ID.
+func writeTracks(w http.ResponseWriter, r *http.Request, plain interface{}, fields map[string]interface{}, tracks []music.Track, page music.Page) {
+	w.Header().Add("Vary", "Accept")
+	if !wantsHAL(r) {
+		writeJSON(w, plain)
+		return
+	}
+
+	embedded := make([]halTrack, len(tracks))
+	for i, t := range tracks {
+		embedded[i] = halTrack{Track: t, Links: map[string]halLink{
+			"analysis": {Href: "/api/tracks/" + url.PathEscape(t.ID) + "/analysis"},
+		}}
+	}
+
+	doc := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		doc[k] = v
+	}
+	doc["paging"] = page
+	doc["_links"] = pageLinks(r, page)
+	doc["_embedded"] = map[string]interface{}{"tracks": embedded}
+
+	w.Header().Set("Content-Type", halMediaType)
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		http.Error(w, "An error occurred while encoding the response", http.StatusInternalServerError)
+	}
+}
+
+// pageLinks returns the self, first, prev and next links of a page, keeping the other query parameters of the request
+func pageLinks(r *http.Request, page music.Page) map[string]halLink {
+	at := func(offset int) halLink {
+		q := r.URL.Query()
+		q.Set("limit", strconv.Itoa(page.Limit))
+		q.Set("offset", strconv.Itoa(offset))
+		return halLink{Href: r.URL.Path + "?" + q.Encode()}
+	}
+
+	links := map[string]halLink{
+		"self":  at(page.Offset),
+		"first": at(0),
+	}
+	if page.Offset > 0 {
+		links["prev"] = at(max(page.Offset-page.Limit, 0))
+	}
+	if page.NextOffset != nil {
+		links["next"] = at(*page.NextOffset)
+	}
+	return links
+}
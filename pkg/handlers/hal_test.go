@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+func TestPageLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		page  music.Page
+		links map[string]string
+	}{
+		{"only page", "/api/search?q=daft", music.NewPage(20, 0, 3, 3), map[string]string{
+			"self":  "/api/search?limit=20&offset=0&q=daft",
+			"first": "/api/search?limit=20&offset=0&q=daft",
+		}},
+		{"first of several", "/api/search?q=daft", music.NewPage(20, 0, 20, 45), map[string]string{
+			"self":  "/api/search?limit=20&offset=0&q=daft",
+			"first": "/api/search?limit=20&offset=0&q=daft",
+			"next":  "/api/search?limit=20&offset=20&q=daft",
+		}},
+		{"middle", "/api/search?q=daft&limit=20&offset=20", music.NewPage(20, 20, 20, 45), map[string]string{
+			"self":  "/api/search?limit=20&offset=20&q=daft",
+			"first": "/api/search?limit=20&offset=0&q=daft",
+			"prev":  "/api/search?limit=20&offset=0&q=daft",
+			"next":  "/api/search?limit=20&offset=40&q=daft",
+		}},
+		{"last", "/api/search?q=daft&offset=40", music.NewPage(20, 40, 5, 45), map[string]string{
+			"self":  "/api/search?limit=20&offset=40&q=daft",
+			"first": "/api/search?limit=20&offset=0&q=daft",
+			"prev":  "/api/search?limit=20&offset=20&q=daft",
+		}},
+		{"prev doesn't go below zero", "/api/playlists/p/tracks?offset=5", music.NewPage(20, 5, 20, 100), map[string]string{
+			"self":  "/api/playlists/p/tracks?limit=20&offset=5",
+			"first": "/api/playlists/p/tracks?limit=20&offset=0",
+			"prev":  "/api/playlists/p/tracks?limit=20&offset=0",
+			"next":  "/api/playlists/p/tracks?limit=20&offset=25",
+		}},
+		{"other parameters are kept and escaped", "/api/search?q=a%26b&explicit=false", music.NewPage(10, 0, 10, 20), map[string]string{
+			"self":  "/api/search?explicit=false&limit=10&offset=0&q=a%26b",
+			"first": "/api/search?explicit=false&limit=10&offset=0&q=a%26b",
+			"next":  "/api/search?explicit=false&limit=10&offset=10&q=a%26b",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := pageLinks(httptest.NewRequest(http.MethodGet, tt.url, nil), tt.page)
+			got := make(map[string]string, len(links))
+			for rel, l := range links {
+				got[rel] = l.Href
+			}
+			if !reflect.DeepEqual(got, tt.links) {
+				t.Errorf("pageLinks() = %v, want %v", got, tt.links)
+			}
+		})
+	}
+}
+
+func TestWantsHAL(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/hal+json", true},
+		{"application/json, application/hal+json;q=0.9", true},
+		{"application/hal+json;q=0", false},
+		{"text/html,*/*", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		if got := wantsHAL(r); got != tt.want {
+			t.Errorf("wantsHAL(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTracksHAL(t *testing.T) {
+	tracks := []music.Track{{ID: "a"}, {ID: "b/c"}}
+	page := music.NewPage(2, 0, 2, 4)
+	r := httptest.NewRequest(http.MethodGet, "/api/search?q=x", nil)
+	r.Header.Set("Accept", halMediaType)
+	w := httptest.NewRecorder()
+	writeTracks(w, r, nil, map[string]interface{}{"query": "x"}, tracks, page)
+
+	if ct := w.Header().Get("Content-Type"); ct != halMediaType {
+		t.Fatalf("Content-Type %q, want %s", ct, halMediaType)
+	}
+	var doc struct {
+		Query    string                `json:"query"`
+		Links    map[string]halLink    `json:"_links"`
+		Embedded map[string][]halTrack `json:"_embedded"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	embedded := doc.Embedded["tracks"]
+	if doc.Query != "x" || doc.Links["next"].Href == "" || len(embedded) != 2 {
+		t.Fatalf("document = %s", w.Body)
+	}
+	if got := embedded[1].Links["analysis"].Href; got != "/api/tracks/b%2Fc/analysis" {
+		t.Errorf("analysis link %q, want the ID escaped", got)
+	}
+}
@@ -1,84 +1,211 @@
-// This file will contain the HTTP handlers that respond to web requests.
-
-package handlers
-
-import (
-	"fmt"
-	"net/http"
-)
-
-// Application struct to hold the methods for routes
-type Application struct{}
-
-// Home is a simple handler function which writes a response.
-// This will display a form on the home page where users can enter a track name and click on the "Search" button to search for the track.
-func (app *Application) Home(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `
-		<h1>Welcome to Smart-Music-Go!</h1>
-		<form action="/search" method="get">
-			<input type="text" name="track" placeholder="Enter a track name">
-			<button type="submit">Search</button>
-		</form>
-	`)
-}
-
-/* In this function, we're getting the track query parameter from the request, 
-creating a new Spotify client, searching for the track, and printing the name of the first track found. 
-You'll need to replace "your-client-id" and "your-client-secret" with your actual Spotify application's client ID and secret.
-
-This will display the name of the track, the name of the artist, and a link to listen to the track on Spotify.
-
-This is a very basic implementation and there's a lot more you can do. 
-For example, you could add pagination to display more search results, 
-add more details about the tracks, handle errors more gracefully, 
-add a login system to allow users to save their favorite tracks, and much more. 
-The possibilities are endless! */
-
-// Search is a handler function which will be used to handle search requests.
-func (app *Application) Search(w http.ResponseWriter, r *http.Request) {
-	// Get the query parameter for the track from the URL
-	track := r.URL.Query().Get("track")
-
-	// Create a new Spotify client using the client ID and secret
-	// Replace "your-client-id" and "your-client-secret" with your actual client ID and secret
-	sc := spotify.NewSpotifyClient("your-client-id", "your-client-secret")
-
-	// Use the Spotify client to search for the track
-	// The SearchTrack function returns the first track found and an error
-	// If no tracks are found, the error will be "no tracks found"
-	// If an error occurs during the search, it will be a different error
-	result, err := sc.SearchTrack(track)
-	if err != nil {
-		// If the error is "no tracks found", respond with a user-friendly message
-		if err.Error() == "no tracks found" {
-			fmt.Fprintf(w, "No tracks found for '%s'", track)
-		} else {
-			// If a different error occurs, respond with a generic server error message
-			http.Error(w, "An error occurred while searching for tracks", http.StatusInternalServerError)
-		}
-		// Stop processing the request
-		return
-	}
-
-	// Parse the "search_results.html" template
-	// The ParseFiles function returns a *Template and an error
-	// If an error occurs while loading the template, it will be a different error
-	tmpl, err := template.ParseFiles("ui/templates/search_results.html")
-	if err != nil {
-		// If an error occurs while loading the template, respond with a generic server error message
-		http.Error(w, "An error occurred while loading the template", http.StatusInternalServerError)
-		// Stop processing the request
-		return
-	}
-
-	// Render the template with the search results
-	// The Execute function writes the rendered template to the http.ResponseWriter
-	// If an error occurs while rendering the template, it will be a different error
-	err = tmpl.Execute(w, result)
-	if err != nil {
-		// If an error occurs while rendering the template, respond with a generic server error message
-		http.Error(w, "An error occurred while rendering the template", http.StatusInternalServerError)
-		// Stop processing the request
-		return
-	}
-}
+// This file will contain the HTTP handlers that respond to web requests.
+
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/bio"
+	"Smart-Music-Go/pkg/events"
+	"Smart-Music-Go/pkg/features"
+	"Smart-Music-Go/pkg/logging"
+	"Smart-Music-Go/pkg/music"
+	"Smart-Music-Go/pkg/quota"
+	"Smart-Music-Go/pkg/spotify"
+	"Smart-Music-Go/pkg/waveform"
+
+	spotifyapi "github.com/zmb3/spotify/v2"
+	"golang.org/x/text/language"
+)
+
+// Application struct to hold the dependencies shared by the routes
+type Application struct {
+	// Spotify is the client used to query the Spotify Web API
+	Spotify *spotify.SpotifyClient
+	// Features holds the feature flags, nil leaves every feature at its default
+	Features *features.Flags
+	// Bios finds the biography of artists, nil leaves artists without one
+	Bios bio.Provider
+	// Events lists the concerts of artists, nil when no events provider is configured
+	Events events.Provider
+	// Waveforms computes the waveform of track previews, nil disables the waveform endpoint
+	Waveforms *waveform.Generator
+	// DefaultMarket is the country (ISO 3166-1 alpha-2) whose catalog is used when a request doesn't pick one.
+	// Empty means results aren't restricted to a country.
+	DefaultMarket string
+	// Dictionary learns the names found by searches to correct misspelled queries, nil disables corrections
+	Dictionary *music.Dictionary
+	// SearchCacheTTL is how long search results are cached, advertised to clients with Cache-Control
+	SearchCacheTTL time.Duration
+	// Errors keeps the most recent server errors for the admin endpoints
+	Errors *ErrorLog
+	// AdminToken protects the admin endpoints; they are disabled when it is empty
+	AdminToken string
+	// CookieKey signs the flash message cookie, flashes are dropped when it is empty
+	CookieKey []byte
+	// Started is the time the application was initialized, used to report uptime
+	Started time.Time
+	// Logger receives the application logs; when nil, logs are discarded
+	Logger *slog.Logger
+	// Quotas tracks the outbound requests made to each provider
+	Quotas *quota.Manager
+}
+
+// logger returns the application logger, or one that discards everything when none was set
+func (app *Application) logger() *slog.Logger {
+	if app.Logger == nil {
+		return logging.Discard()
+	}
+	return app.Logger
+}
+
+// Home is a simple handler function which writes a response.
+// This will display a form on the home page where users can enter a track name and click on the "Search" button to search for the track.
+func (app *Application) Home(w http.ResponseWriter, r *http.Request) {
+	app.render(w, r, http.StatusOK, "home.html", "Search", nil)
+}
+
+/* In this function, we're getting the track query parameter from the request,
+searching for the track with the application's Spotify client, and printing the name of the first track found.
+The client ID and secret come from the configuration loaded in main.
+
+This will display the name of the track, the name of the artist, and a link to listen to the track on Spotify.
+
+This is a very basic implementation and there's a lot more you can do.
+For example, you could add pagination to display more search results,
+add more details about the tracks, handle errors more gracefully,
+add a login system to allow users to save their favorite tracks, and much more.
+The possibilities are endless! */
+
+// Search is a handler function which will be used to handle search requests.
+func (app *Application) Search(w http.ResponseWriter, r *http.Request) {
+	// Get the query parameter for the track from the URL
+	track := r.URL.Query().Get("track")
+
+	// Split the query into text and filters such as artist:"Daft Punk" or year:2001-2007,
+	// or find the track a pasted Spotify link points to
+	// A query that can't be searched goes back to the search form, which explains why
+	query, trackID, err := resolveQuery(track)
+	if err != nil {
+		app.flashRedirect(w, r, "/", "error", err.Error())
+		return
+	}
+
+	// Get the country whose catalog should be searched
+	market, err := app.market(r)
+	if err != nil {
+		app.flashRedirect(w, r, "/", "error", err.Error())
+		return
+	}
+
+	// The track filters of the API apply to the page too, e.g. explicit=false
+	filter, err := parseTrackFilter(r)
+	if err != nil {
+		app.flashRedirect(w, r, "/", "error", err.Error())
+		return
+	}
+
+	// Use the Spotify client to search for the track
+	// The SearchTrack function returns the first track found and an error
+	// If no tracks are found, the error will be "no tracks found"
+	// If an error occurs during the search, it will be a different error
+	var result spotifyapi.FullTrack
+	if trackID != "" {
+		result, err = app.Spotify.Track(r.Context(), trackID, market)
+	} else {
+		result, err = app.Spotify.SearchTrack(r.Context(), query, market)
+	}
+	if err != nil && err.Error() != "no tracks found" {
+		// Report the error, asking the user to come back later if we've used up the Spotify quota
+		app.providerError(w, r, "An error occurred while searching for tracks", err)
+		// Stop processing the request
+		return
+	}
+
+	// Render the "search_results.html" template in the layout
+	// When no tracks are found, or the track found is filtered out, the page says so instead of showing a track
+	data := struct {
+		Query string
+		Track *music.Track
+	}{Query: track}
+	if err == nil {
+		kept, err := app.filterTracks(r, []music.Track{spotify.ToTrack(result)}, filter)
+		if err != nil {
+			app.providerError(w, r, "An error occurred while analysing the tracks", err)
+			return
+		}
+		if len(kept) > 0 {
+			data.Track = &kept[0]
+		}
+	}
+	app.pageCacheControl(w)
+	app.render(w, r, http.StatusOK, "search_results.html", "Search results", data)
+}
+
+// RequireFeature wraps the handler of an optional feature, which doesn't exist while its flag is off
+func (app *Application) RequireFeature(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.Features.Enabled(name) {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cacheControl lets browsers and proxies reuse search results while they are cached here.
+// They don't depend on the user, so they can be shared.
+func (app *Application) cacheControl(w http.ResponseWriter) {
+	if app.SearchCacheTTL > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(app.SearchCacheTTL.Seconds())))
+		// The market, and so the results, can come from the preferred language
+		w.Header().Set("Vary", "Accept-Language")
+	}
+}
+
+// pageCacheControl lets browsers reuse the HTML page of search results while they are cached here.
+// Pages depend on the visitor's cookies (theme, flash), so shared caches must not keep them.
+func (app *Application) pageCacheControl(w http.ResponseWriter) {
+	if app.SearchCacheTTL > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(app.SearchCacheTTL.Seconds())))
+		w.Header().Set("Vary", "Accept-Language")
+	}
+}
+
+// market returns the country code requested with the market query parameter.
+// Without one, the country of the browser's preferred language is used,
+// and the default market when the language doesn't name a country either.
+func (app *Application) market(r *http.Request) (string, error) {
+	market := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("market")))
+	if market == "" {
+		if region := languageRegion(r.Header.Get("Accept-Language")); region != "" {
+			return region, nil
+		}
+		return app.DefaultMarket, nil
+	}
+	if !music.ValidMarket(market) {
+		return "", fmt.Errorf("market must be a two-letter country code such as US or GB")
+	}
+	return market, nil
+}
+
+// languageRegion returns the country of the most preferred language in an Accept-Language header that names one,
+// e.g. "FR" for "fr-FR,fr;q=0.9". Languages without a country, like "fr", are skipped.
+func languageRegion(header string) string {
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil {
+		return ""
+	}
+	for _, tag := range tags {
+		region, confidence := tag.Region()
+		// Regions that are only guessed from the language aren't what the user asked for
+		if confidence == language.Exact && music.ValidMarket(region.String()) {
+			return region.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+// This file will contain the JSON handlers to browse Spotify playlists.
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"Smart-Music-Go/pkg/music"
+	"Smart-Music-Go/pkg/quota"
+	"Smart-Music-Go/pkg/spotify"
+
+	spotifyapi "github.com/zmb3/spotify/v2"
+)
+
+// Paging defaults and bounds, matching what the Spotify API accepts
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// PlaylistTracksResponse is the JSON document returned by /api/playlists/{id}/tracks
+type PlaylistTracksResponse struct {
+	PlaylistID string        `json:"playlist_id"`
+	Tracks     []music.Track `json:"tracks"`
+	Paging     music.Page    `json:"paging"`
+}
+
+// PlaylistTracks responds with a page of the tracks of a public Spotify playlist.
+// The page is selected with the optional limit (1-100, default 50) and offset query parameters,
+// and the optional market parameter relinks tracks to versions available in that country.
+// The optional track filters (see parseTrackFilter) drop the tracks of the page that don't match.
+// Clients accepting application/hal+json get links to the other pages.
+func (app *Application) PlaylistTracks(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !music.ValidSpotifyID(id) {
+		http.Error(w, "Invalid playlist ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := pageParams(r, defaultPageLimit, maxPageLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseTrackFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tracks, items, total, err := app.Spotify.PlaylistTracks(r.Context(), id, market, limit, offset)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while fetching the playlist", err)
+		return
+	}
+
+	// Paging follows the items of the playlist, not what is left after skipping episodes and filtering
+	page := music.NewPage(limit, offset, items, total)
+	tracks, err = app.filterTracks(r, tracks, filter)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while analysing the tracks", err)
+		return
+	}
+	writeTracks(w, r, PlaylistTracksResponse{PlaylistID: id, Tracks: tracks, Paging: page},
+		map[string]interface{}{"playlist_id": id}, tracks, page)
+}
+
+// pageParams reads the limit and offset query parameters, applying the default limit and its upper bound
+func pageParams(r *http.Request, defaultLimit, maxLimit int) (limit, offset int, err error) {
+	limit, err = limitParam(r, defaultLimit, maxLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// limitParam reads the limit query parameter, applying the default and upper bound
+func limitParam(r *http.Request, defaultLimit, maxLimit int) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be a number between 1 and %d", maxLimit)
+	}
+	return limit, nil
+}
+
+// providerError translates an error from a provider into the matching HTTP response:
+// unknown resources become 404, rejected input 400, an exhausted quota 503, unavailable track analyses 501,
+// and anything else a generic 500. Nothing is written when the client has gone away.
+func (app *Application) providerError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	// The client went away while waiting, there is no one left to answer and nothing went wrong here
+	if r.Context().Err() != nil && errors.Is(err, r.Context().Err()) {
+		return
+	}
+
+	var exhausted *quota.ExhaustedError
+	if errors.As(err, &exhausted) {
+		unavailable(w, "Spotify is busy right now, please try again in a few minutes", exhausted.RetryAfter)
+		return
+	}
+
+	// Track analyses depend on an API Spotify no longer opens to new applications, which is no fault of the server
+	if errors.Is(err, spotify.ErrAnalysisUnavailable) {
+		http.Error(w, "Track analysis is not available on this server", http.StatusNotImplemented)
+		return
+	}
+
+	var apiErr spotifyapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusNotFound:
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		case http.StatusBadRequest:
+			http.Error(w, "Invalid request: "+apiErr.Message, http.StatusBadRequest)
+			return
+		}
+	}
+
+	app.serverError(w, r, message, err)
+}
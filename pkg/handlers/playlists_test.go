@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"Smart-Music-Go/pkg/spotify"
+
+	spotifyapi "github.com/zmb3/spotify/v2"
+)
+
+// testPlaylistID is a well-formed playlist ID
+const testPlaylistID = "37i9dQZF1DXcBWIGoYBM5M"
+
+// fakePlaylist serves the items of a playlist the way the Spotify API pages them
+func fakePlaylist(t *testing.T, items []string) *spotify.SpotifyClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		end := offset + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		var page []string
+		if offset < end {
+			page = items[offset:end]
+		}
+		fmt.Fprintf(w, `{"items":[%s],"total":%d,"limit":%d,"offset":%d}`, strings.Join(page, ","), len(items), limit, offset)
+	}))
+	t.Cleanup(srv.Close)
+	return &spotify.SpotifyClient{Client: spotifyapi.New(srv.Client(), spotifyapi.WithBaseURL(srv.URL+"/"))}
+}
+
+func playlistTrack(id string) string {
+	return fmt.Sprintf(`{"track":{"type":"track","id":%q,"name":%q,"artists":[],"album":{"name":"album"}}}`, id, id)
+}
+
+func TestPlaylistTracksPagingSkipsItems(t *testing.T) {
+	unavailable := `{"track":null}`
+	episode := `{"track":{"type":"episode","id":"e","name":"episode"}}`
+
+	tests := []struct {
+		name  string
+		items []string
+		limit int
+		want  []string
+	}{
+		{"only tracks", []string{playlistTrack("a"), playlistTrack("b"), playlistTrack("c")}, 2, []string{"a", "b", "c"}},
+		{"unavailable and episode items", []string{playlistTrack("a"), unavailable, episode, playlistTrack("b"), playlistTrack("c")}, 3, []string{"a", "b", "c"}},
+		{"page without tracks", []string{unavailable, episode, playlistTrack("a")}, 2, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Application{Spotify: fakePlaylist(t, tt.items)}
+
+			var got []string
+			offset := 0
+			for pages := 0; ; pages++ {
+				if pages > len(tt.items) {
+					t.Fatal("paging doesn't end")
+				}
+				r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/playlists/%s/tracks?limit=%d&offset=%d", testPlaylistID, tt.limit, offset), nil)
+				r.SetPathValue("id", testPlaylistID)
+				w := httptest.NewRecorder()
+				app.PlaylistTracks(w, r)
+				if w.Code != http.StatusOK {
+					t.Fatalf("status %d: %s", w.Code, w.Body)
+				}
+
+				var resp PlaylistTracksResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				for _, track := range resp.Tracks {
+					got = append(got, track.ID)
+				}
+				if resp.Paging.NextOffset == nil {
+					break
+				}
+				offset = *resp.Paging.NextOffset
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("tracks across pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// uncalledSpotify fails the test if the handler calls Spotify, for the requests that must be rejected first
+func uncalledSpotify(t *testing.T) *spotify.SpotifyClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Spotify called with %s", r.URL)
+	}))
+	t.Cleanup(srv.Close)
+	return &spotify.SpotifyClient{Client: spotifyapi.New(srv.Client(), spotifyapi.WithBaseURL(srv.URL+"/"))}
+}
+
+// invalidIDs are path values that must not reach the Spotify API, built around the well-formed id
+func invalidIDs(id string) []string {
+	return []string{"", "p", "../../me", id + "/tracks", id[:21] + ",", id + "&market=US"}
+}
+
+func TestPlaylistTracksRejectsInvalidIDs(t *testing.T) {
+	app := &Application{Spotify: uncalledSpotify(t)}
+
+	for _, id := range invalidIDs(testPlaylistID) {
+		r := httptest.NewRequest(http.MethodGet, "/api/playlists/x/tracks", nil)
+		r.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		app.PlaylistTracks(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("id %q: status %d, want 400", id, w.Code)
+		}
+	}
+}
+
+func TestPageParams(t *testing.T) {
+	tests := []struct {
+		query         string
+		limit, offset int
+		err           string
+	}{
+		{"", 50, 0, ""},
+		{"limit=10&offset=0", 10, 0, ""},
+		{"offset=20", 50, 20, ""},
+		{"offset=-1", 0, 0, "offset must be a non-negative number"},
+		{"offset=x", 0, 0, "offset must be a non-negative number"},
+		{"limit=0", 0, 0, "limit must be a number between 1 and 100"},
+		{"limit=101", 0, 0, "limit must be a number between 1 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			limit, offset, err := pageParams(r, defaultPageLimit, maxPageLimit)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("pageParams() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || limit != tt.limit || offset != tt.offset {
+				t.Errorf("pageParams() = %d, %d, %v, want %d, %d", limit, offset, err, tt.limit, tt.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// This file will contain the rendering of HTML pages in the shared layout, with the data every page needs.
+
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"Smart-Music-Go/pkg/middleware"
+)
+
+// templateDir is where the HTML templates live, relative to the working directory of the server
+const templateDir = "ui/templates"
+
+// themeCookie remembers the colour theme picked by the visitor
+const themeCookie = "theme"
+
+// themes are the accepted values of the theme cookie, auto follows the operating system
+var themes = map[string]bool{"auto": true, "light": true, "dark": true}
+
+// navLink is an entry of the navigation bar
+type navLink struct {
+	Href  string
+	Label string
+}
+
+// pageData is what every template receives: the data shared by the layout, and Page for the page itself
+type pageData struct {
+	Title string
+	Theme string
+	Nav   []navLink
+	// Flash is the one-time message left by the previous request, if any
+	Flash *Flash
+	Page  interface{}
+}
+
+// render executes the page template within the base layout and writes it with the given status.
+// The page is rendered to a buffer first, so a template error becomes a clean 500 instead of half a page.
+func (app *Application) render(w http.ResponseWriter, r *http.Request, status int, page, title string, data interface{}) {
+	tmpl, err := template.ParseFiles(filepath.Join(templateDir, "base.html"), filepath.Join(templateDir, page))
+	if err != nil {
+		app.serverError(w, r, "An error occurred while loading the template", err)
+		return
+	}
+
+	var buf bytes.Buffer
+	_, noFlash := r.Cookie(flashCookie)
+	flash := app.popFlash(w, r)
+	err = tmpl.ExecuteTemplate(&buf, "base", pageData{
+		Title: title,
+		Theme: theme(r),
+		Nav:   []navLink{{Href: "/", Label: "Search"}},
+		Flash: flash,
+		Page:  data,
+	})
+	if err != nil {
+		app.serverError(w, r, "An error occurred while rendering the template", err)
+		return
+	}
+
+	// The theme comes from a cookie, so shared caches must keep a copy per theme
+	w.Header().Add("Vary", "Cookie")
+	// A page carrying a flash, or deleting one, is meant for this visitor, once
+	if noFlash == nil {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
+
+// theme returns the theme picked by the visitor, auto when none was picked
+func theme(r *http.Request) string {
+	if c, err := r.Cookie(themeCookie); err == nil && themes[c.Value] {
+		return c.Value
+	}
+	return "auto"
+}
+
+// SetTheme remembers the theme posted by the form in the navigation bar and goes back to the page it was posted from
+func (app *Application) SetTheme(w http.ResponseWriter, r *http.Request) {
+	value := r.PostFormValue("theme")
+	if !themes[value] {
+		app.flashRedirect(w, r, backTo(r), "error", "The theme must be auto, light or dark")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     themeCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		Secure:   middleware.Scheme(r) == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, backTo(r), http.StatusSeeOther)
+}
+
+// backTo returns the local page a form was posted from, or the home page.
+// Only the path and query of the Referer are kept, so the redirect can't lead to another site.
+func backTo(r *http.Request) string {
+	ref, err := url.Parse(r.Referer())
+	// Browsers read "//host" and "/\host" as another site
+	if err != nil || ref.Path == "" || ref.Path[0] != '/' || strings.HasPrefix(ref.Path, "//") || strings.Contains(ref.Path, "\\") {
+		return "/"
+	}
+	ref.Scheme, ref.Host, ref.User, ref.Fragment = "", "", nil, ""
+	return ref.RequestURI()
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSetThemeCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		tls    bool
+		secure bool
+	}{
+		{"plain HTTP", false, false},
+		{"HTTPS", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Application{}
+			r := httptest.NewRequest(http.MethodPost, "/theme", strings.NewReader(url.Values{"theme": {"dark"}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			app.SetTheme(w, r)
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != themeCookie || cookies[0].Value != "dark" {
+				t.Fatalf("cookies = %v, want the theme cookie", cookies)
+			}
+			if c := cookies[0]; c.Secure != tt.secure || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode {
+				t.Errorf("theme cookie Secure %v, HttpOnly %v, SameSite %v, want Secure %v, HttpOnly and Lax", c.Secure, c.HttpOnly, c.SameSite, tt.secure)
+			}
+		})
+	}
+}
+
+func TestBackTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		referer string
+		want    string
+	}{
+		{"no Referer", "", "/"},
+		{"local page", "http://example.com/search", "/search"},
+		{"path with a query", "http://example.com/search?track=daft+punk&explicit=false#top", "/search?track=daft+punk&explicit=false"},
+		{"relative path", "/search?track=x", "/search?track=x"},
+		{"another host", "https://evil.example/phish?x=1", "/phish?x=1"},
+		{"protocol-relative URL", "//evil.example", "/"},
+		{"protocol-relative path", "http://example.com//evil.example", "/"},
+		{"backslash", `/\evil.example`, "/"},
+		{"encoded slashes", "http://example.com/%2F%2Fevil.example", "/"},
+		{"not a path", "javascript:alert(1)", "/"},
+		{"malformed", "http://[::1", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/theme", nil)
+			if tt.referer != "" {
+				r.Header.Set("Referer", tt.referer)
+			}
+			if got := backTo(r); got != tt.want {
+				t.Errorf("backTo() with Referer %q = %q, want %q", tt.referer, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// This file will contain the JSON handlers of the search API.
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"Smart-Music-Go/pkg/music"
+	"Smart-Music-Go/pkg/spotify"
+)
+
+const (
+	// defaultSearchLimit and maxSearchLimit bound the page size of /api/search, Spotify returns at most 50 results at a time
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	// defaultSearchAllLimit is the number of results of each type returned by /api/search/all
+	defaultSearchAllLimit = 5
+	// minSuggestQuery is the shortest query worth suggesting anything for
+	minSuggestQuery = 2
+	// defaultSuggestLimit and maxSuggestLimit bound the number of suggestions returned
+	defaultSuggestLimit = 5
+	maxSuggestLimit     = 10
+)
+
+// SearchResponse is the JSON body returned by SearchJSON
+type SearchResponse struct {
+	Query string `json:"query"`
+	// DidYouMean is the corrected query whose results are returned, when the query as typed found nothing
+	DidYouMean string        `json:"did_you_mean,omitempty"`
+	Tracks     []music.Track `json:"tracks"`
+	Paging     music.Page    `json:"paging"`
+}
+
+// SearchJSON responds with a page of the tracks matching the q query parameter, which accepts the same filters as the search page.
+// q may also be a Spotify track link or an ISRC, which can be given with the isrc parameter instead (but not both).
+// The page is selected with the optional limit (1-50, default 20) and offset query parameters.
+// When nothing matches, the query is retried with its misspelled words corrected.
+// The optional track filters (see parseTrackFilter) drop the tracks of the page that don't match,
+// so a filtered page can hold fewer than limit tracks, and paging carries on from the unfiltered offset.
+// Clients accepting application/hal+json get links to the other pages.
+func (app *Application) SearchJSON(w http.ResponseWriter, r *http.Request) {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if isrc := r.URL.Query().Get("isrc"); isrc != "" {
+		if text != "" {
+			http.Error(w, "q and isrc can't be used together", http.StatusBadRequest)
+			return
+		}
+		if _, ok := music.ParseReference(isrc); !ok {
+			http.Error(w, "isrc must be a 12 character code such as USUM71703861", http.StatusBadRequest)
+			return
+		}
+		text = isrc
+	}
+	if text == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	query, trackID, err := resolveQuery(text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r, defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseTrackFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A link selects a single track, which makes a single page
+	if trackID != "" {
+		track, err := app.Spotify.Track(r.Context(), trackID, market)
+		if err != nil {
+			app.providerError(w, r, "An error occurred while fetching the track", err)
+			return
+		}
+		tracks, err := app.filterTracks(r, []music.Track{spotify.ToTrack(track)}, filter)
+		if err != nil {
+			app.providerError(w, r, "An error occurred while analysing the tracks", err)
+			return
+		}
+		page := music.NewPage(limit, 0, len(tracks), len(tracks))
+		writeTracks(w, r, SearchResponse{Query: text, Tracks: tracks, Paging: page}, map[string]interface{}{"query": text}, tracks, page)
+		return
+	}
+
+	tracks, total, err := app.Spotify.SearchTracks(r.Context(), query, market, limit, offset)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while searching for tracks", err)
+		return
+	}
+
+	response := SearchResponse{Query: text}
+	if len(tracks) == 0 && offset == 0 {
+		if corrected, ok := app.correct(r, text, market, limit); ok {
+			response.DidYouMean = corrected.query
+			tracks, total = corrected.tracks, corrected.total
+		}
+	}
+	app.learn(tracks)
+
+	// Paging follows what was fetched from Spotify, not what is left after filtering
+	response.Paging = music.NewPage(limit, offset, len(tracks), total)
+	response.Tracks, err = app.filterTracks(r, tracks, filter)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while analysing the tracks", err)
+		return
+	}
+	app.cacheControl(w)
+	fields := map[string]interface{}{"query": response.Query}
+	if response.DidYouMean != "" {
+		fields["did_you_mean"] = response.DidYouMean
+	}
+	writeTracks(w, r, response, fields, response.Tracks, response.Paging)
+}
+
+// resolveQuery interprets the text typed in the search box.
+// A Spotify link or URI selects a track, whose ID is returned, an ISRC searches for the recordings carrying it,
+// and anything else is parsed as a query with filters.
+// Links to other services are recognised but rejected, as only Spotify can be searched.
+func resolveQuery(text string) (query music.Query, trackID string, err error) {
+	ref, ok := music.ParseReference(text)
+	switch {
+	case !ok:
+		query, err = music.ParseQuery(text)
+		return query, "", err
+	case ref.ISRC != "":
+		return music.Query{ISRC: ref.ISRC}, "", nil
+	case ref.Provider == "spotify":
+		return music.Query{}, ref.ID, nil
+	default:
+		return music.Query{}, "", fmt.Errorf("links to %s aren't supported, only Spotify links are", ref.Provider)
+	}
+}
+
+// correction is the first page of results of a corrected query
+type correction struct {
+	query  string
+	tracks []music.Track
+	total  int
+}
+
+// correct searches again with the misspelled words of text replaced by known names.
+// It reports false when there is nothing to correct or the corrected query finds nothing either.
+// The retry is best effort: a failure is logged and the original empty result stands.
+func (app *Application) correct(r *http.Request, text, market string, limit int) (correction, bool) {
+	if app.Dictionary == nil || !app.Features.Enabled("spelling_correction") {
+		return correction{}, false
+	}
+	corrected, changed := app.Dictionary.Correct(text)
+	if !changed {
+		return correction{}, false
+	}
+	query, err := music.ParseQuery(corrected)
+	if err != nil {
+		return correction{}, false
+	}
+	tracks, total, err := app.Spotify.SearchTracks(r.Context(), query, market, limit, 0)
+	if err != nil {
+		app.logger().Warn("corrected search failed", "query", corrected, "err", err)
+		return correction{}, false
+	}
+	if len(tracks) == 0 {
+		return correction{}, false
+	}
+	return correction{query: corrected, tracks: tracks, total: total}, true
+}
+
+// learn adds the names of tracks found by a search to the dictionary used to correct queries
+func (app *Application) learn(tracks []music.Track) {
+	if app.Dictionary != nil {
+		app.Dictionary.AddTracks(tracks)
+	}
+}
+
+// SearchAllResponse is the JSON body returned by SearchAll
+type SearchAllResponse struct {
+	Query string `json:"query"`
+	music.SearchResults
+}
+
+// SearchAll responds with the tracks, artists, albums and playlists matching the q query parameter,
+// so a search page can show grouped results from a single request.
+// The optional limit parameter (1-50, default 5) is the number of results of each type,
+// and the track filters (see parseTrackFilter) apply to the tracks.
+func (app *Application) SearchAll(w http.ResponseWriter, r *http.Request) {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	query, err := music.ParseQuery(text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := limitParam(r, defaultSearchAllLimit, maxSearchLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseTrackFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := app.Spotify.SearchAll(r.Context(), query, market, limit)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while searching", err)
+		return
+	}
+	app.learn(results.Tracks)
+	results.Tracks, err = app.filterTracks(r, results.Tracks, filter)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while analysing the tracks", err)
+		return
+	}
+
+	app.cacheControl(w)
+	writeJSON(w, SearchAllResponse{Query: text, SearchResults: results})
+}
+
+// SuggestResponse is the JSON body returned by Suggest
+type SuggestResponse struct {
+	Query       string             `json:"query"`
+	Suggestions []music.Suggestion `json:"suggestions"`
+}
+
+// Suggest responds with artist and track names completing the q query parameter, for a typeahead in the search box.
+// The optional limit parameter (1-10, default 5) caps the number of suggestions.
+func (app *Application) Suggest(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	limit, err := limitParam(r, defaultSuggestLimit, maxSuggestLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A single letter matches nearly everything, so don't spend an API call on it
+	suggestions := []music.Suggestion{}
+	if utf8.RuneCountInString(query) >= minSuggestQuery {
+		suggestions, err = app.Spotify.Suggest(r.Context(), query, market, limit)
+		if err != nil {
+			app.providerError(w, r, "An error occurred while fetching suggestions", err)
+			return
+		}
+	}
+
+	app.cacheControl(w)
+	writeJSON(w, SuggestResponse{Query: query, Suggestions: suggestions})
+}
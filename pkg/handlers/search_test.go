@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"Smart-Music-Go/pkg/spotify"
+
+	spotifyapi "github.com/zmb3/spotify/v2"
+)
+
+func TestSearchJSONRejectsBadQueries(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"", "q is required"},
+		{"q=+", "q is required"},
+		{"isrc=nope", "isrc must be a 12 character code such as USUM71703861"},
+		{"q=daft&isrc=USUM71703861", "q and isrc can't be used together"},
+		{"q=year:nope", `year: "nope" is not a year`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			app := &Application{}
+			w := httptest.NewRecorder()
+			app.SearchJSON(w, httptest.NewRequest(http.MethodGet, "/api/search?"+tt.query, nil))
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.err) {
+				t.Errorf("status %d, body %q, want 400 with %q", w.Code, w.Body, tt.err)
+			}
+		})
+	}
+}
+
+// testTrackID is a well-formed track ID
+const testTrackID = "4uLU6hMCjMI75M1A2tKUQC"
+
+// fakeTrack serves a single track, explicit or not, the way the Spotify API does
+func fakeTrack(t *testing.T, explicit bool) *spotify.SpotifyClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tracks/"+testTrackID {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,"name":"Never Gonna Give You Up","explicit":%v,"artists":[{"name":"Rick Astley"}],"album":{"name":"Whenever You Need Somebody"}}`, testTrackID, explicit)
+	}))
+	t.Cleanup(srv.Close)
+	return &spotify.SpotifyClient{Client: spotifyapi.New(srv.Client(), spotifyapi.WithBaseURL(srv.URL+"/"))}
+}
+
+func TestSearchJSONLinkFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit bool
+		filter   string
+		want     int
+	}{
+		{"no filter", true, "", 1},
+		{"explicit track filtered out", true, "&explicit=false", 0},
+		{"clean track kept", false, "&explicit=false", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Application{Spotify: fakeTrack(t, tt.explicit)}
+			w := httptest.NewRecorder()
+			app.SearchJSON(w, httptest.NewRequest(http.MethodGet, "/api/search?q=https://open.spotify.com/track/"+testTrackID+tt.filter, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+
+			var resp SearchResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Tracks) != tt.want || resp.Paging.Total != tt.want {
+				t.Errorf("%d tracks out of %d, want %d", len(resp.Tracks), resp.Paging.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchLinkFilters(t *testing.T) {
+	// The templates are found relative to the root of the repository
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		name   string
+		filter string
+		want   string
+	}{
+		{"no filter", "", "Never Gonna Give You Up"},
+		{"explicit track filtered out", "&explicit=false", "No tracks found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &Application{Spotify: fakeTrack(t, true)}
+			w := httptest.NewRecorder()
+			app.Search(w, httptest.NewRequest(http.MethodGet, "/search?track=spotify:track:"+testTrackID+tt.filter, nil))
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("status %d, body %q, want 200 with %q", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
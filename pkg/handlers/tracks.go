@@ -0,0 +1,74 @@
+// This file will contain the JSON handlers giving details about individual tracks.
+
+package handlers
+
+import (
+	"net/http"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// TrackAnalysis responds with the tempo (BPM), key, mode, Camelot key and time signature of a track
+func (app *Application) TrackAnalysis(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !music.ValidSpotifyID(id) {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+
+	analyses, err := app.Spotify.TrackAnalyses(r.Context(), []string{id})
+	if err != nil {
+		app.providerError(w, r, "An error occurred while analysing the track", err)
+		return
+	}
+
+	analysis, ok := analyses[id]
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, analysis)
+}
+
+// TrackWaveform responds with the waveform of a track's preview, for a player to draw.
+// The waveform is computed from the preview on the first request and cached.
+// It is computed while the request waits: the preview is small and only its frame headers are read.
+// Tracks without a preview are not found.
+func (app *Application) TrackWaveform(w http.ResponseWriter, r *http.Request) {
+	if app.Waveforms == nil {
+		http.NotFound(w, r)
+		return
+	}
+	id := r.PathValue("id")
+	if !music.ValidSpotifyID(id) {
+		http.Error(w, "Invalid track ID", http.StatusBadRequest)
+		return
+	}
+	// A bad market is rejected even when the waveform is cached, so the answer doesn't depend on the cache
+	market, err := app.market(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if waveform, ok := app.Waveforms.Cached(r.Context(), id); ok {
+		writeJSON(w, waveform)
+		return
+	}
+
+	track, err := app.Spotify.Track(r.Context(), id, market)
+	if err != nil {
+		app.providerError(w, r, "An error occurred while fetching the track", err)
+		return
+	}
+	if track.PreviewURL == "" {
+		http.Error(w, "This track has no preview", http.StatusNotFound)
+		return
+	}
+
+	waveform, err := app.Waveforms.Generate(r.Context(), id, track.PreviewURL, "spotify")
+	if err != nil {
+		app.providerError(w, r, "An error occurred while computing the waveform", err)
+		return
+	}
+	writeJSON(w, waveform)
+}
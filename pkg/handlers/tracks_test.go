@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Smart-Music-Go/pkg/cache"
+	"Smart-Music-Go/pkg/waveform"
+)
+
+func TestTrackHandlersRejectBadRequests(t *testing.T) {
+	// The waveform of testTrackID is cached, a bad request must be rejected anyway
+	store := cache.NewMemory(time.Hour, 10)
+	store.Set(context.Background(), testTrackID, []byte(`{"track_id":"`+testTrackID+`","peaks":[0.5]}`))
+	waveforms := waveform.New(nil)
+	waveforms.EnableCache(store)
+	app := &Application{Spotify: uncalledSpotify(t), Waveforms: waveforms}
+
+	handlers := map[string]http.HandlerFunc{"analysis": app.TrackAnalysis, "waveform": app.TrackWaveform}
+	for name, handler := range handlers {
+		for _, id := range invalidIDs(testTrackID) {
+			r := httptest.NewRequest(http.MethodGet, "/api/tracks/x/"+name, nil)
+			r.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s of %q: status %d, want 400", name, id, w.Code)
+			}
+		}
+	}
+
+	for market, want := range map[string]int{"US": http.StatusOK, "usa": http.StatusBadRequest} {
+		r := httptest.NewRequest(http.MethodGet, "/api/tracks/x/waveform?market="+market, nil)
+		r.SetPathValue("id", testTrackID)
+		w := httptest.NewRecorder()
+		app.TrackWaveform(w, r)
+		if w.Code != want {
+			t.Errorf("cached waveform with market %s: status %d, want %d", market, w.Code, want)
+		}
+	}
+}
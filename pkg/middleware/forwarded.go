@@ -0,0 +1,157 @@
+// This file will derive the real client address, scheme and host of requests that come through a reverse proxy.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// contextKey is the type of the keys this package stores in request contexts
+type contextKey int
+
+const originKey contextKey = iota
+
+// origin describes where a request really came from
+type origin struct {
+	scheme   string
+	host     string
+	clientIP string
+}
+
+// ParseTrustedProxies converts a list of IP addresses and CIDR ranges into networks.
+// A bare IP address is treated as a single-address network.
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// Forwarded returns middleware that trusts the X-Forwarded-For, X-Forwarded-Proto
+// and X-Forwarded-Host headers only when the direct peer is one of the trusted proxies.
+// Handlers read the result with ClientIP, Scheme and Host.
+// Headers sent by anyone else are ignored, so clients cannot spoof their address.
+func Forwarded(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			o := directOrigin(r)
+
+			if len(trusted) > 0 && isTrusted(net.ParseIP(o.clientIP), trusted) {
+				if ip := forwardedClient(r.Header.Values("X-Forwarded-For"), trusted); ip != "" {
+					o.clientIP = ip
+				}
+				if proto := strings.ToLower(firstValue(r.Header.Get("X-Forwarded-Proto"))); proto == "http" || proto == "https" {
+					o.scheme = proto
+				}
+				if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+					o.host = host
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), originKey, o)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the IP address of the client that made the request
+func ClientIP(r *http.Request) string {
+	return requestOrigin(r).clientIP
+}
+
+// Scheme returns "https" or "http" depending on how the client reached the server
+func Scheme(r *http.Request) string {
+	return requestOrigin(r).scheme
+}
+
+// Host returns the host name (and port, if any) the client used to reach the server
+func Host(r *http.Request) string {
+	return requestOrigin(r).host
+}
+
+// requestOrigin returns the origin stored by Forwarded,
+// or the values of the direct connection when the middleware is not installed.
+func requestOrigin(r *http.Request) origin {
+	if o, ok := r.Context().Value(originKey).(origin); ok {
+		return o
+	}
+	return directOrigin(r)
+}
+
+// directOrigin describes the request as seen on the TCP connection
+func directOrigin(r *http.Request) origin {
+	o := origin{scheme: "http", host: r.Host, clientIP: r.RemoteAddr}
+	if r.TLS != nil {
+		o.scheme = "https"
+	}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		o.clientIP = ip
+	}
+	return o
+}
+
+// forwardedClient walks the X-Forwarded-For chain from the right, skipping trusted proxies,
+// and returns the first address that is not one of them.
+// If every hop is trusted the left-most address is the client.
+func forwardedClient(values []string, trusted []*net.IPNet) string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			// A malformed hop means the rest of the chain can't be trusted
+			return ""
+		}
+		if !isTrusted(ip, trusted) || i == 0 {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// isTrusted reports whether ip belongs to one of the trusted networks
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstValue returns the first element of a comma-separated header value
+func firstValue(v string) string {
+	first, _, _ := strings.Cut(v, ",")
+	return strings.TrimSpace(first)
+}
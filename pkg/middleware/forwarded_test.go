@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestForwarded(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		peer    string
+		tls     bool
+		headers map[string][]string
+		// want is the client IP, scheme and host seen by the handler
+		wantIP, wantScheme, wantHost string
+	}{
+		{
+			name:   "direct client",
+			peer:   "203.0.113.7:5000",
+			wantIP: "203.0.113.7", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "direct TLS client",
+			peer: "203.0.113.7:5000", tls: true,
+			wantIP: "203.0.113.7", wantScheme: "https", wantHost: "example.com",
+		},
+		{
+			name: "untrusted peer spoofing the headers",
+			peer: "203.0.113.7:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"198.51.100.1"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"evil.example"},
+			},
+			wantIP: "203.0.113.7", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "trusted proxy",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For":   {"198.51.100.1"},
+				"X-Forwarded-Proto": {"HTTPS"},
+				"X-Forwarded-Host":  {"music.example, proxy.internal"},
+			},
+			wantIP: "198.51.100.1", wantScheme: "https", wantHost: "music.example",
+		},
+		{
+			name: "spoofed left-most hop",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"1.2.3.4, 198.51.100.1, 192.168.1.1"},
+			},
+			wantIP: "198.51.100.1", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "chain split over several headers",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"1.2.3.4", "198.51.100.1, 192.168.1.1"},
+			},
+			wantIP: "198.51.100.1", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "every hop trusted",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"192.168.1.2, 192.168.1.1"},
+			},
+			wantIP: "192.168.1.2", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "malformed hop",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1, not-an-ip"},
+			},
+			wantIP: "10.0.0.1", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "unknown scheme",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"X-Forwarded-Proto": {"javascript"},
+			},
+			wantIP: "10.0.0.1", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "malformed Forwarded header is ignored",
+			peer: "10.0.0.1:5000",
+			headers: map[string][]string{
+				"Forwarded": {`for="198.51.100.1;proto=https;host=evil.example`},
+			},
+			wantIP: "10.0.0.1", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "IPv6 trusted proxy",
+			peer: "[fd00::1]:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"2001:db8::7"},
+			},
+			wantIP: "2001:db8::7", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "IPv6 untrusted peer",
+			peer: "[2001:db8::7]:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			wantIP: "2001:db8::7", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name: "IPv4-mapped IPv6 proxy",
+			peer: "[::ffff:10.0.0.1]:5000",
+			headers: map[string][]string{
+				"X-Forwarded-For": {"198.51.100.1"},
+			},
+			wantIP: "198.51.100.1", wantScheme: "http", wantHost: "example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ip, scheme, host string
+			h := Forwarded(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip, scheme, host = ClientIP(r), Scheme(r), Host(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			r.RemoteAddr = tt.peer
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			for key, values := range tt.headers {
+				for _, v := range values {
+					r.Header.Add(key, v)
+				}
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			if ip != tt.wantIP || scheme != tt.wantScheme || host != tt.wantHost {
+				t.Errorf("got %s, %s, %s, want %s, %s, %s", ip, scheme, host, tt.wantIP, tt.wantScheme, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		entries []string
+		ok      bool
+	}{
+		{nil, true},
+		{[]string{"10.0.0.1", "::1", "192.168.0.0/16", "fd00::/8"}, true},
+		{[]string{"proxy.local"}, false},
+		{[]string{"10.0.0.0/33"}, false},
+	}
+	for _, tt := range tests {
+		_, err := ParseTrustedProxies(tt.entries)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseTrustedProxies(%q) = %v, want ok %v", tt.entries, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// This file will protect the server from oversized requests and from more concurrent requests than it can handle.
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxBodySize returns middleware limiting request bodies to maxBytes.
+// Handlers reading past the limit get an error, and net/http closes the connection.
+// A limit of zero or less disables it.
+func MaxBodySize(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ConcurrencyLimiter caps the number of requests being served at the same time,
+// for the whole server and for each client IP.
+type ConcurrencyLimiter struct {
+	// slots is a semaphore holding one token per request in flight, nil when unlimited
+	slots      chan struct{}
+	perClient  int
+	retryAfter time.Duration
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewConcurrencyLimiter allows up to maxInFlight requests server-wide and perClient requests per client IP.
+// A limit of zero or less disables it.
+// Rejected clients are told to retry after retryAfter.
+func NewConcurrencyLimiter(maxInFlight, perClient int, retryAfter time.Duration) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{
+		perClient:  perClient,
+		retryAfter: retryAfter,
+		inFlight:   make(map[string]int),
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// Middleware returns middleware enforcing the limits.
+// A client over its own limit gets 429; when the whole server is saturated the request
+// is shed immediately with 503 rather than queued, so latency stays bounded under load.
+func (l *ConcurrencyLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := ClientIP(r)
+		if !l.acquireClient(client) {
+			l.reject(w, "Too many concurrent requests", http.StatusTooManyRequests)
+			return
+		}
+		defer l.releaseClient(client)
+
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+				defer func() { <-l.slots }()
+			default:
+				l.reject(w, "Server is busy, please try again shortly", http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquireClient counts a new request for client, unless it already has perClient requests in flight
+func (l *ConcurrencyLimiter) acquireClient(client string) bool {
+	if l.perClient <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight[client] >= l.perClient {
+		return false
+	}
+	l.inFlight[client]++
+	return true
+}
+
+// releaseClient uncounts a finished request, forgetting clients with nothing in flight
+func (l *ConcurrencyLimiter) releaseClient(client string) {
+	if l.perClient <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight[client] <= 1 {
+		delete(l.inFlight, client)
+		return
+	}
+	l.inFlight[client]--
+}
+
+// reject responds with status and a Retry-After header
+func (l *ConcurrencyLimiter) reject(w http.ResponseWriter, message string, status int) {
+	if l.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(l.retryAfter.Round(time.Second).Seconds())))
+	}
+	http.Error(w, message, status)
+}
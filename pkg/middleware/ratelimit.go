@@ -0,0 +1,150 @@
+// This file will limit how often clients can call the routes that reach the Spotify API.
+
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are removed from memory
+const sweepInterval = time.Minute
+
+// bucket is the token bucket of a single key
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter decides whether a request identified by key (e.g. a client IP) may proceed.
+// RateLimiter keeps its state in memory; RedisLimiter shares it between instances.
+type Limiter interface {
+	// Allow takes a token from the bucket of key.
+	// It reports whether the request may proceed, how many tokens are left,
+	// and how long until the next token is available.
+	Allow(ctx context.Context, key string) (ok bool, remaining int, retryAfter time.Duration, err error)
+	// PerMinute returns the number of requests allowed per minute, reported in X-RateLimit-Limit
+	PerMinute() int
+}
+
+// RateLimiter is an in-memory token bucket Limiter.
+// Each key gets a bucket of burst tokens that refills at the configured rate.
+type RateLimiter struct {
+	perMinute int
+	burst     int
+
+	mu        sync.Mutex
+	perSecond float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter returns a limiter allowing perMinute requests per minute per key,
+// with bursts of up to burst requests. perMinute must be positive.
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimiter{
+		perMinute: perMinute,
+		burst:     burst,
+		perSecond: float64(perMinute) / 60,
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// PerMinute implements Limiter
+func (l *RateLimiter) PerMinute() int {
+	return l.perMinute
+}
+
+// Allow implements Limiter. It never fails.
+func (l *RateLimiter) Allow(_ context.Context, key string) (ok bool, remaining int, retryAfter time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	// Refill the bucket for the time elapsed since the last request
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+		return false, 0, wait, nil
+	}
+	b.tokens--
+	return true, int(b.tokens), 0, nil
+}
+
+// sweep drops the buckets that have refilled completely, they are identical to a new bucket.
+// The caller must hold l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.perSecond >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimit returns middleware enforcing a server-wide limit and a per-client-IP limit.
+// Either limiter may be nil to disable it.
+// The per-client state is reported in the X-RateLimit-* headers and rejected requests get a 429 with Retry-After.
+// If a limiter fails (e.g. Redis is unreachable) the error is logged and the request is let through,
+// so an outage of the limiter's store doesn't take the site down.
+func RateLimit(global, perIP Limiter, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if perIP != nil {
+				ok, remaining, retryAfter, err := perIP.Allow(r.Context(), "ip:"+ClientIP(r))
+				if err != nil {
+					logger.Error("rate limiter failed", "limiter", "per_ip", "err", err)
+				} else {
+					w.Header().Set("X-RateLimit-Limit", strconv.Itoa(perIP.PerMinute()))
+					w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+					if !ok {
+						tooManyRequests(w, retryAfter)
+						return
+					}
+				}
+			}
+
+			if global != nil {
+				ok, _, retryAfter, err := global.Allow(r.Context(), "global")
+				if err != nil {
+					logger.Error("rate limiter failed", "limiter", "global", "err", err)
+				} else if !ok {
+					tooManyRequests(w, retryAfter)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tooManyRequests responds with 429 and tells the client when to retry, rounded up to whole seconds
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	w.Header().Set("Retry-After", seconds)
+	w.Header().Set("X-RateLimit-Reset", seconds)
+	http.Error(w, "Too many requests, please slow down", http.StatusTooManyRequests)
+}
@@ -0,0 +1,83 @@
+// This file will implement the rate limiter on top of Redis so every instance shares the same buckets.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes a token from a bucket atomically.
+// The Redis clock is used so instances with skewed clocks agree.
+// It returns whether the request is allowed and the tokens left (as a string, to keep the fraction).
+var tokenBucketScript = redis.NewScript(`
+local perSecond = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * perSecond)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(burst / perSecond) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter is a token bucket Limiter whose buckets live in Redis
+type RedisLimiter struct {
+	client    *redis.Client
+	prefix    string
+	perMinute int
+	burst     int
+}
+
+// NewRedisLimiter returns a Limiter allowing perMinute requests per minute per key, with bursts of up to burst.
+// Buckets are stored under prefix. perMinute must be positive.
+func NewRedisLimiter(client *redis.Client, prefix string, perMinute, burst int) *RedisLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RedisLimiter{client: client, prefix: prefix, perMinute: perMinute, burst: burst}
+}
+
+// PerMinute implements Limiter
+func (l *RedisLimiter) PerMinute() int {
+	return l.perMinute
+}
+
+// Allow implements Limiter
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (bool, int, time.Duration, error) {
+	perSecond := float64(l.perMinute) / 60
+	res, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, perSecond, l.burst).Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	if len(res) != 2 {
+		return false, 0, 0, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokensStr, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("parsing rate limit tokens: %w", err)
+	}
+
+	if allowed != 1 {
+		return false, 0, time.Duration((1 - tokens) / perSecond * float64(time.Second)), nil
+	}
+	return true, int(tokens), 0, nil
+}
@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeClock lets tests move time forward
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestLimiter(perMinute, burst int) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewRateLimiter(perMinute, burst)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestRateLimiterBucket(t *testing.T) {
+	type step struct {
+		// advance moves the clock forward before the request
+		advance   time.Duration
+		ok        bool
+		remaining int
+		retry     time.Duration
+	}
+	tests := []struct {
+		name             string
+		perMinute, burst int
+		steps            []step
+	}{
+		{"burst then exhausted", 60, 3, []step{
+			{0, true, 2, 0},
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{0, false, 0, time.Second},
+			{0, false, 0, time.Second},
+		}},
+		{"refills over time", 60, 2, []step{
+			{0, true, 1, 0},
+			{0, true, 0, 0},
+			{500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+			{500 * time.Millisecond, true, 0, 0},
+			{0, false, 0, time.Second},
+		}},
+		{"refill stops at the burst", 60, 2, []step{
+			{0, true, 1, 0},
+			{time.Hour, true, 1, 0},
+			{0, true, 0, 0},
+			{0, false, 0, time.Second},
+		}},
+		{"slow rate", 6, 1, []step{
+			{0, true, 0, 0},
+			{time.Second, false, 0, 9 * time.Second},
+			{9 * time.Second, true, 0, 0},
+		}},
+		{"zero burst allows one request", 60, 0, []step{
+			{0, true, 0, 0},
+			{0, false, 0, time.Second},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, clock := newTestLimiter(tt.perMinute, tt.burst)
+			for i, s := range tt.steps {
+				clock.t = clock.t.Add(s.advance)
+				ok, remaining, retry, err := l.Allow(context.Background(), "ip:1.2.3.4")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok != s.ok || remaining != s.remaining || retry != s.retry {
+					t.Fatalf("request %d: Allow() = %v, %d, %v, want %v, %d, %v", i, ok, remaining, retry, s.ok, s.remaining, s.retry)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(60, 1)
+	ctx := context.Background()
+	if ok, _, _, _ := l.Allow(ctx, "ip:1.1.1.1"); !ok {
+		t.Fatal("first request of 1.1.1.1 rejected")
+	}
+	if ok, _, _, _ := l.Allow(ctx, "ip:1.1.1.1"); ok {
+		t.Fatal("second request of 1.1.1.1 allowed")
+	}
+	if ok, _, _, _ := l.Allow(ctx, "ip:2.2.2.2"); !ok {
+		t.Fatal("another client is limited by the first one")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l, clock := newTestLimiter(60, 2)
+	ctx := context.Background()
+	l.Allow(ctx, "ip:1.1.1.1")
+	l.Allow(ctx, "ip:2.2.2.2")
+	l.Allow(ctx, "ip:2.2.2.2")
+
+	// 1.1.1.1 is full again after a second, 2.2.2.2 needs two
+	l.sweep(clock.t.Add(1500 * time.Millisecond))
+	if _, ok := l.buckets["ip:1.1.1.1"]; ok {
+		t.Error("refilled bucket not swept")
+	}
+	if _, ok := l.buckets["ip:2.2.2.2"]; !ok {
+		t.Error("bucket swept before it refilled")
+	}
+}
+
+// failingLimiter is a Limiter whose store is down
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string) (bool, int, time.Duration, error) {
+	return false, 0, 0, errors.New("store unreachable")
+}
+
+func (failingLimiter) PerMinute() int { return 60 }
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// unreachable is a Redis limiter whose server is down, it fails every call
+	unreachable := NewRedisLimiter(redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		},
+	}), "test:", 60, 1)
+
+	type request struct {
+		ip   string
+		code int
+	}
+	tests := []struct {
+		name     string
+		global   func() Limiter
+		perIP    func() Limiter
+		requests []request
+	}{
+		{"no limits", nil, nil, []request{
+			{"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusOK},
+		}},
+		{"per IP", nil, func() Limiter { l, _ := newTestLimiter(60, 2); return l }, []request{
+			{"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusTooManyRequests},
+			{"2.2.2.2", http.StatusOK},
+		}},
+		{"global", func() Limiter { l, _ := newTestLimiter(60, 2); return l }, nil, []request{
+			{"1.1.1.1", http.StatusOK}, {"2.2.2.2", http.StatusOK}, {"3.3.3.3", http.StatusTooManyRequests},
+		}},
+		{"global and per IP", func() Limiter { l, _ := newTestLimiter(60, 3); return l }, func() Limiter { l, _ := newTestLimiter(60, 1); return l }, []request{
+			{"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusTooManyRequests},
+			{"2.2.2.2", http.StatusOK}, {"3.3.3.3", http.StatusOK}, {"4.4.4.4", http.StatusTooManyRequests},
+		}},
+		{"failing limiters let requests through", func() Limiter { return failingLimiter{} }, func() Limiter { return failingLimiter{} }, []request{
+			{"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusOK},
+		}},
+		{"unreachable Redis lets requests through", func() Limiter { return unreachable }, func() Limiter { return unreachable }, []request{
+			{"1.1.1.1", http.StatusOK}, {"1.1.1.1", http.StatusOK},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var global, perIP Limiter
+			if tt.global != nil {
+				global = tt.global()
+			}
+			if tt.perIP != nil {
+				perIP = tt.perIP()
+			}
+			h := RateLimit(global, perIP, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i, req := range tt.requests {
+				r := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+				r.RemoteAddr = req.ip + ":5000"
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code != req.code {
+					t.Fatalf("request %d from %s: status %d, want %d", i, req.ip, w.Code, req.code)
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "1" {
+					t.Errorf("request %d: Retry-After %q, want 1", i, w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	l, _ := newTestLimiter(30, 2)
+	h := RateLimit(nil, l, slog.New(slog.NewTextHandler(io.Discard, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	want := []struct{ remaining, retryAfter string }{{"1", ""}, {"0", ""}, {"0", "2"}}
+	for i, wt := range want {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/search", nil))
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "30" {
+			t.Errorf("request %d: X-RateLimit-Limit %q, want 30", i, got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != wt.remaining {
+			t.Errorf("request %d: X-RateLimit-Remaining %q, want %s", i, got, wt.remaining)
+		}
+		if got := w.Header().Get("Retry-After"); got != wt.retryAfter {
+			t.Errorf("request %d: Retry-After %q, want %q", i, got, wt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// This file will describe the musical analysis of a track (tempo, key) and the Camelot wheel used by DJs to mix in key.
+
+package music
+
+import "fmt"
+
+// keyNames are the pitch classes in the order used by the providers (0 = C)
+var keyNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// Analysis holds the musical properties of a track
+type Analysis struct {
+	TrackID string `json:"track_id"`
+	// Tempo is the estimated tempo in beats per minute
+	Tempo float64 `json:"tempo"`
+	// Energy measures intensity and activity, from 0 (calm) to 1 (loud, fast and noisy)
+	Energy float64 `json:"energy"`
+	// Key is the pitch class name (e.g. "F#"), empty when it could not be detected
+	Key string `json:"key"`
+	// Mode is "major" or "minor"
+	Mode string `json:"mode"`
+	// Camelot is the key in Camelot notation (e.g. "8B"), empty when the key is unknown
+	Camelot string `json:"camelot"`
+	// CompatibleKeys lists the Camelot keys that mix harmonically with this one
+	CompatibleKeys []string `json:"compatible_keys"`
+	// TimeSignature is the estimated number of beats per bar
+	TimeSignature int    `json:"time_signature"`
+	Provider      string `json:"provider"`
+}
+
+// NewAnalysis builds an Analysis from a pitch class (0 = C, -1 = unknown) and a mode (1 = major, 0 = minor)
+func NewAnalysis(trackID string, tempo float64, pitchClass, mode, timeSignature int, provider string) Analysis {
+	a := Analysis{
+		TrackID:        trackID,
+		Tempo:          tempo,
+		Mode:           "minor",
+		CompatibleKeys: []string{},
+		TimeSignature:  timeSignature,
+		Provider:       provider,
+	}
+	major := mode == 1
+	if major {
+		a.Mode = "major"
+	}
+	if pitchClass >= 0 && pitchClass < len(keyNames) {
+		a.Key = keyNames[pitchClass]
+		a.Camelot = Camelot(pitchClass, major)
+		a.CompatibleKeys = CompatibleKeys(pitchClass, major)
+	}
+	return a
+}
+
+// Camelot returns the position of a key on the Camelot wheel: a number from 1 to 12,
+// followed by B for major keys and A for minor keys. Adjacent numbers are a fifth apart.
+func Camelot(pitchClass int, major bool) string {
+	letter := "B"
+	if !major {
+		// A minor key sits at the same number as its relative major, three semitones up
+		pitchClass = (pitchClass + 3) % 12
+		letter = "A"
+	}
+	return fmt.Sprintf("%d%s", camelotNumber(pitchClass), letter)
+}
+
+// CompatibleKeys returns the keys that mix well with the given one:
+// the same key, one step either way around the wheel, and the relative major or minor.
+func CompatibleKeys(pitchClass int, major bool) []string {
+	relative := pitchClass
+	if !major {
+		relative = (pitchClass + 3) % 12
+	}
+	n := camelotNumber(relative)
+	letter, other := "B", "A"
+	if !major {
+		letter, other = "A", "B"
+	}
+	down := (n+10)%12 + 1
+	up := n%12 + 1
+	return []string{
+		fmt.Sprintf("%d%s", n, letter),
+		fmt.Sprintf("%d%s", down, letter),
+		fmt.Sprintf("%d%s", up, letter),
+		fmt.Sprintf("%d%s", n, other),
+	}
+}
+
+// camelotNumber returns the wheel number of a major key: C is 8 and each fifth adds one
+func camelotNumber(majorPitchClass int) int {
+	n := (7*majorPitchClass + 8) % 12
+	if n == 0 {
+		n = 12
+	}
+	return n
+}
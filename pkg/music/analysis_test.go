@@ -0,0 +1,68 @@
+package music
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCamelot(t *testing.T) {
+	// The Camelot wheel as printed by Mixed In Key, by pitch class
+	majors := [12]string{"8B", "3B", "10B", "5B", "12B", "7B", "2B", "9B", "4B", "11B", "6B", "1B"}
+	minors := [12]string{"5A", "12A", "7A", "2A", "9A", "4A", "11A", "6A", "1A", "8A", "3A", "10A"}
+	for pc := 0; pc < 12; pc++ {
+		if got := Camelot(pc, true); got != majors[pc] {
+			t.Errorf("Camelot(%s major) = %s, want %s", keyNames[pc], got, majors[pc])
+		}
+		if got := Camelot(pc, false); got != minors[pc] {
+			t.Errorf("Camelot(%s minor) = %s, want %s", keyNames[pc], got, minors[pc])
+		}
+	}
+}
+
+func TestCompatibleKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		pitchClass int
+		major      bool
+		want       []string
+	}{
+		{"C major", 0, true, []string{"8B", "7B", "9B", "8A"}},
+		{"A minor", 9, false, []string{"8A", "7A", "9A", "8B"}},
+		{"E major wraps up to 1", 4, true, []string{"12B", "11B", "1B", "12A"}},
+		{"G# minor wraps down to 12", 8, false, []string{"1A", "12A", "2A", "1B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompatibleKeys(tt.pitchClass, tt.major); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompatibleKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAnalysis(t *testing.T) {
+	tests := []struct {
+		name              string
+		pitchClass, mode  int
+		key, wantMode     string
+		camelot           string
+		compatibleKeysLen int
+	}{
+		{"F# major", 6, 1, "F#", "major", "2B", 4},
+		{"D minor", 2, 0, "D", "minor", "7A", 4},
+		{"unknown key", -1, 1, "", "major", "", 0},
+		{"out of range key", 12, 0, "", "minor", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalysis("id", 120, tt.pitchClass, tt.mode, 4, "spotify")
+			if a.Key != tt.key || a.Mode != tt.wantMode || a.Camelot != tt.camelot || len(a.CompatibleKeys) != tt.compatibleKeysLen {
+				t.Errorf("NewAnalysis() = %+v, want key %q, mode %s, camelot %q and %d compatible keys", a, tt.key, tt.wantMode, tt.camelot, tt.compatibleKeysLen)
+			}
+			// An unknown key must still encode as an empty list, not null
+			if a.CompatibleKeys == nil {
+				t.Error("CompatibleKeys is nil")
+			}
+		})
+	}
+}
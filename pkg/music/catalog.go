@@ -0,0 +1,88 @@
+// This file will contain the artists, albums and playlists of the provider-neutral model.
+
+package music
+
+// Artist is an artist as exposed by the API
+type Artist struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Genres      []string `json:"genres"`
+	ImageURL    string   `json:"image_url,omitempty"`
+	ExternalURL string   `json:"external_url,omitempty"`
+	Provider    string   `json:"provider"`
+}
+
+// ArtistDetails is an artist with their discography, as returned by the artist endpoint
+type ArtistDetails struct {
+	Artist
+	Followers  int `json:"followers"`
+	Popularity int `json:"popularity"`
+	// Bio is a short biography, nil when none was found
+	Bio       *Bio    `json:"bio"`
+	TopTracks []Track `json:"top_tracks"`
+	// Albums are the albums, singles and compilations of the artist
+	Albums         []Album  `json:"albums"`
+	RelatedArtists []Artist `json:"related_artists"`
+}
+
+// Bio is a short biography of an artist and where it comes from
+type Bio struct {
+	Summary string `json:"summary"`
+	URL     string `json:"url"`
+	// Source is the site the biography was taken from, e.g. "wikipedia"
+	Source string `json:"source"`
+}
+
+// Album is an album, single or compilation as exposed by the API
+type Album struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Artists []string `json:"artists"`
+	// Type is "album", "single" or "compilation"
+	Type string `json:"type"`
+	// ReleaseDate is as precise as the provider knows it: "1981", "1981-12" or "1981-12-15"
+	ReleaseDate string `json:"release_date"`
+	TotalTracks int    `json:"total_tracks"`
+	ImageURL    string `json:"image_url,omitempty"`
+	ExternalURL string `json:"external_url,omitempty"`
+	Provider    string `json:"provider"`
+}
+
+// AlbumDetails is an album with its complete tracklist, as returned by the album endpoint
+type AlbumDetails struct {
+	Album
+	Genres []string `json:"genres"`
+	// Copyrights are the copyright and phonographic copyright notices, which usually name the label
+	Copyrights []string `json:"copyrights"`
+	// UPC is the barcode of the release, when the provider knows it
+	UPC        string `json:"upc,omitempty"`
+	Popularity int    `json:"popularity"`
+	// Tracks are in album order, disc by disc
+	Tracks []AlbumTrack `json:"tracks"`
+}
+
+// AlbumTrack is a track in the tracklist of an album
+type AlbumTrack struct {
+	Track
+	DiscNumber  int `json:"disc_number"`
+	TrackNumber int `json:"track_number"`
+}
+
+// Playlist is a public playlist as exposed by the API
+type Playlist struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	TotalTracks int    `json:"total_tracks"`
+	ImageURL    string `json:"image_url,omitempty"`
+	ExternalURL string `json:"external_url,omitempty"`
+	Provider    string `json:"provider"`
+}
+
+// SearchResults groups the matches of a search by type
+type SearchResults struct {
+	Tracks    []Track    `json:"tracks"`
+	Artists   []Artist   `json:"artists"`
+	Albums    []Album    `json:"albums"`
+	Playlists []Playlist `json:"playlists"`
+}
@@ -0,0 +1,169 @@
+// This file will contain the dictionary of known names used to correct misspelled searches.
+
+package music
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bounds of the corrections, keeping the work done for a query small
+const (
+	// minCorrectedLength is the shortest word corrected, shorter ones are too close to too many words
+	minCorrectedLength = 3
+	// maxCorrectedLength is the longest word corrected, longer ones aren't names
+	maxCorrectedLength = 30
+	// maxCorrectedWords is the number of words of a query looked at, the rest are left alone
+	maxCorrectedWords = 10
+)
+
+// Dictionary collects the words of the artist, track and album names seen in search results,
+// and suggests corrections for misspelled queries from them.
+// It is safe for concurrent use.
+type Dictionary struct {
+	mu sync.RWMutex
+	// words counts how often each word was seen, the most common wins ties between corrections
+	words map[string]int
+	// lengths indexes the words by their length in runes, as only words of about the same length are close
+	lengths map[int][]string
+	max     int
+}
+
+// NewDictionary creates a dictionary holding up to max distinct words.
+// Once full, new words are ignored, the common ones are learnt first anyway.
+func NewDictionary(max int) *Dictionary {
+	return &Dictionary{words: make(map[string]int), lengths: make(map[int][]string), max: max}
+}
+
+// Add learns the words of the given names
+func (d *Dictionary) Add(names ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, name := range names {
+		for _, word := range strings.FieldsFunc(strings.ToLower(name), isSeparator) {
+			if _, ok := d.words[word]; ok {
+				d.words[word]++
+			} else if len(d.words) < d.max {
+				d.words[word] = 1
+				length := utf8.RuneCountInString(word)
+				d.lengths[length] = append(d.lengths[length], word)
+			}
+		}
+	}
+}
+
+// AddTracks learns the names of tracks, their artists and albums
+func (d *Dictionary) AddTracks(tracks []Track) {
+	for _, t := range tracks {
+		d.Add(t.Name, t.Album)
+		d.Add(t.Artists...)
+	}
+}
+
+// Correct replaces the unknown words of text with the closest known word, if there is one close enough.
+// Filters (words containing a colon), quoted values, ISRCs, very short or long words
+// and the words past the first few are left alone.
+// Replacements keep the capitalisation of the word they replace.
+// It returns the corrected text and whether anything changed.
+func (d *Dictionary) Correct(text string) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	// splitQuery keeps a quoted value in one token, spaces included
+	words := splitQuery(text)
+	changed := false
+	for i, word := range words {
+		if i == maxCorrectedWords {
+			break
+		}
+		length := utf8.RuneCountInString(word)
+		if length < minCorrectedLength || length > maxCorrectedLength || strings.ContainsAny(word, `:"`) {
+			continue
+		}
+		if isrcPattern.MatchString(strings.ToUpper(strings.ReplaceAll(word, "-", ""))) {
+			continue
+		}
+		lower := strings.ToLower(word)
+		if _, ok := d.words[lower]; ok {
+			continue
+		}
+		if best := d.closest(lower, length); best != "" {
+			words[i] = matchCase(word, best)
+			changed = true
+		}
+	}
+	return strings.Join(words, " "), changed
+}
+
+// matchCase capitalises the lower-case word like original: all upper case or only the first letter
+func matchCase(original, word string) string {
+	if strings.ToUpper(original) == original && strings.ToLower(original) != original {
+		return strings.ToUpper(word)
+	}
+	if first, _ := utf8.DecodeRuneInString(original); unicode.IsUpper(first) {
+		r, size := utf8.DecodeRuneInString(word)
+		return string(unicode.ToUpper(r)) + word[size:]
+	}
+	return word
+}
+
+// closest returns the known word with the fewest edits from word, of the given length in runes,
+// or "" when none is close enough.
+// Short words only tolerate a single typo, or everything would match everything.
+func (d *Dictionary) closest(word string, length int) string {
+	maxDistance := 1
+	if length > 4 {
+		maxDistance = 2
+	}
+
+	best, bestDistance, bestCount := "", maxDistance+1, 0
+	// Each edit changes the length by one at most, the other words are too far anyway
+	for l := length - maxDistance; l <= length+maxDistance; l++ {
+		for _, candidate := range d.lengths[l] {
+			distance := editDistance(word, candidate)
+			count := d.words[candidate]
+			if distance < bestDistance || (distance == bestDistance && count > bestCount) {
+				best, bestDistance, bestCount = candidate, distance, count
+			}
+		}
+	}
+	if bestDistance > maxDistance {
+		return ""
+	}
+	return best
+}
+
+// isSeparator reports whether r separates the words of a name
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and swaps of adjacent letters turning a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Only the last three rows of the matrix are needed
+	before := make([]int, len(rb)+1)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				current[j] = min(current[j], before[j-2]+1)
+			}
+		}
+		before, previous, current = previous, current, before
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,55 @@
+package music
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDictionaryCorrect(t *testing.T) {
+	d := NewDictionary(100)
+	d.Add("Radiohead", "Karma Police", "Nirvana", "Nevermind", "The Beatles", "Abbey Road", "Queen", "ABBA", "Daft Punk", "GBAYE0601498")
+	d.Add("Queen")
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		changed bool
+	}{
+		{"known words", "karma police", "karma police", false},
+		{"one typo", "radiohed", "radiohead", true},
+		{"swapped letters", "nirvnaa", "nirvana", true},
+		{"two typos in a long word", "nevrmnd", "nevermind", true},
+		{"two typos in a short word", "qeun", "qeun", false},
+		{"too far", "metallica", "metallica", false},
+		{"short words are left alone", "ab th", "ab th", false},
+		{"filters and quotes are left alone", `artist:radiohed "nirvan"`, `artist:radiohed "nirvan"`, false},
+		{"every word of a quoted value is left alone", `artist:"daft pnuk rocks" pnuk`, `artist:"daft pnuk rocks" punk`, true},
+		{"quoted phrase is left alone", `"radiohed nirvan"`, `"radiohed nirvan"`, false},
+		{"ISRCs are left alone", "GBAYE0601499 gb-aye-06-01497", "GBAYE0601499 gb-aye-06-01497", false},
+		{"capitalised word", "Daft Pnuk", "Daft Punk", true},
+		{"upper-case word", "RADIOHED", "RADIOHEAD", true},
+		{"long words are left alone", strings.Repeat("a", maxCorrectedLength+1), strings.Repeat("a", maxCorrectedLength+1), false},
+		{"words past the limit are left alone", strings.Repeat("x ", maxCorrectedWords) + "radiohed", strings.TrimSpace(strings.Repeat("x ", maxCorrectedWords)) + " radiohed", false},
+		{"words within the limit", strings.Repeat("x ", maxCorrectedWords-1) + "radiohed", strings.TrimSpace(strings.Repeat("x ", maxCorrectedWords-1)) + " radiohead", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := d.Correct(tt.text)
+			if got != tt.want || changed != tt.changed {
+				t.Errorf("Correct(%q) = %q, %v, want %q, %v", tt.text, got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestDictionaryMax(t *testing.T) {
+	d := NewDictionary(2)
+	d.Add("alpha beta gamma")
+	if got, _ := d.Correct("gamm"); got != "gamm" {
+		t.Errorf("Correct(%q) = %q, words past the maximum should not be learnt", "gamm", got)
+	}
+	if got, _ := d.Correct("alph"); got != "alpha" {
+		t.Errorf("Correct(%q) = %q, want %q", "alph", got, "alpha")
+	}
+}
@@ -0,0 +1,126 @@
+// This file will contain the parser of the structured search syntax, e.g. artist:"Daft Punk" year:2001-2007 genre:house.
+
+package music
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed search query.
+// Providers translate the fields to their own filters, or fold them into the text when they have none.
+type Query struct {
+	// Text is what's left of the query once the filters are removed
+	Text   string
+	Artist string
+	Track  string
+	Album  string
+	Genre  string
+	ISRC   string
+	// YearFrom and YearTo bound the release year, zero when not set.
+	// A single year sets both.
+	YearFrom int
+	YearTo   int
+}
+
+// queryFields are the filters understood by ParseQuery.
+// Any other word followed by a colon is kept as text, so "AC/DC: Live" still searches for what was typed.
+var queryFields = map[string]bool{"artist": true, "track": true, "album": true, "genre": true, "isrc": true, "year": true}
+
+// ParseQuery splits a search query into free text and filters.
+// Filters are written field:value, with double quotes around values containing spaces.
+// An error is returned for malformed years and filters given twice.
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	var text []string
+	seen := make(map[string]bool)
+
+	for _, token := range splitQuery(s) {
+		field, value, ok := strings.Cut(token, ":")
+		field = strings.ToLower(field)
+		if !ok || !queryFields[field] || value == "" {
+			text = append(text, strings.Trim(token, `"`))
+			continue
+		}
+		if seen[field] {
+			return Query{}, fmt.Errorf("%s: is given more than once", field)
+		}
+		seen[field] = true
+
+		value = strings.Trim(value, `"`)
+		switch field {
+		case "artist":
+			q.Artist = value
+		case "track":
+			q.Track = value
+		case "album":
+			q.Album = value
+		case "genre":
+			q.Genre = value
+		case "isrc":
+			q.ISRC = strings.ToUpper(value)
+		case "year":
+			from, to, err := parseYears(value)
+			if err != nil {
+				return Query{}, err
+			}
+			q.YearFrom, q.YearTo = from, to
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// splitQuery splits s on spaces, except within double quotes
+func splitQuery(s string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// parseYears parses a year ("2001") or a range of years ("2001-2007")
+func parseYears(value string) (from, to int, err error) {
+	start, end, isRange := strings.Cut(value, "-")
+	if from, err = parseYear(start); err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	if to, err = parseYear(end); err != nil {
+		return 0, 0, err
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("year: %d-%d starts after it ends", from, to)
+	}
+	return from, to, nil
+}
+
+// parseYear parses a four-digit year
+func parseYear(s string) (int, error) {
+	year, err := strconv.Atoi(s)
+	if err != nil || len(s) != 4 {
+		return 0, fmt.Errorf("year: %q is not a year such as 2001 or a range such as 2001-2007", s)
+	}
+	return year, nil
+}
@@ -0,0 +1,50 @@
+package music
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Query
+		err   string
+	}{
+		{"", Query{}, ""},
+		{"daft punk", Query{Text: "daft punk"}, ""},
+		{"  around   the world  ", Query{Text: "around the world"}, ""},
+		{`artist:"Daft Punk" year:2001-2007 genre:house`, Query{Artist: "Daft Punk", Genre: "house", YearFrom: 2001, YearTo: 2007}, ""},
+		{"one more time artist:daft", Query{Text: "one more time", Artist: "daft"}, ""},
+		{"ARTIST:Daft Track:Aerodynamic album:Discovery", Query{Artist: "Daft", Track: "Aerodynamic", Album: "Discovery"}, ""},
+		{"isrc:gbduw0000059", Query{ISRC: "GBDUW0000059"}, ""},
+		{"year:2001", Query{YearFrom: 2001, YearTo: 2001}, ""},
+		{`"around the world"`, Query{Text: "around the world"}, ""},
+		{"AC/DC: Live", Query{Text: "AC/DC: Live"}, ""},
+		{"mood:happy", Query{Text: "mood:happy"}, ""},
+		{"artist: daft", Query{Text: "artist: daft"}, ""},
+		{`artist:"Daft Punk`, Query{Artist: "Daft Punk"}, ""},
+		{"artist:a artist:b", Query{}, "artist: is given more than once"},
+		{"year:01", Query{}, `year: "01" is not a year such as 2001 or a range such as 2001-2007`},
+		{"year:2001-", Query{}, `year: "" is not a year such as 2001 or a range such as 2001-2007`},
+		{"year:2007-2001", Query{}, "year: 2007-2001 starts after it ends"},
+		{"year:twenty", Query{}, `year: "twenty" is not a year such as 2001 or a range such as 2001-2007`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("ParseQuery(%q) error = %v, want %q", tt.query, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// This file will contain the detection of links, URIs and ISRCs pasted in the search box.
+
+package music
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Reference points to a track directly rather than describing it, e.g. a link copied from a streaming service
+type Reference struct {
+	// Provider is the service the link comes from, e.g. "spotify", empty for an ISRC
+	Provider string
+	// ID is the provider's track ID
+	ID string
+	// ISRC is the International Standard Recording Code of the track
+	ISRC string
+}
+
+var (
+	// isrcPattern matches an ISRC without hyphens: country, registrant, year and designation code
+	isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+	// spotifyIDPattern matches the base-62 IDs Spotify gives its tracks, albums, artists and playlists
+	spotifyIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+)
+
+// ValidSpotifyID reports whether id looks like a Spotify ID.
+// IDs taken from requests must pass it before they are put in an API path or a cache key.
+func ValidSpotifyID(id string) bool {
+	return spotifyIDPattern.MatchString(id)
+}
+
+// ParseReference recognises a track link from Spotify, YouTube or Apple Music, a spotify:track: URI or an ISRC.
+// It reports false for anything else, which should be searched as text.
+func ParseReference(s string) (Reference, bool) {
+	s = strings.TrimSpace(s)
+
+	if isrc := strings.ToUpper(strings.ReplaceAll(s, "-", "")); isrcPattern.MatchString(isrc) {
+		return Reference{ISRC: isrc}, true
+	}
+	if id, ok := strings.CutPrefix(s, "spotify:track:"); ok && spotifyIDPattern.MatchString(id) {
+		return Reference{Provider: "spotify", ID: id}, true
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return Reference{}, false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch strings.TrimPrefix(u.Hostname(), "www.") {
+	case "open.spotify.com":
+		// Localised links look like /intl-fr/track/{id}
+		if len(segments) > 0 && strings.HasPrefix(segments[0], "intl-") {
+			segments = segments[1:]
+		}
+		if len(segments) == 2 && segments[0] == "track" && spotifyIDPattern.MatchString(segments[1]) {
+			return Reference{Provider: "spotify", ID: segments[1]}, true
+		}
+	case "youtube.com", "music.youtube.com":
+		if id := u.Query().Get("v"); id != "" {
+			return Reference{Provider: "youtube", ID: id}, true
+		}
+	case "youtu.be":
+		if len(segments) == 1 && segments[0] != "" {
+			return Reference{Provider: "youtube", ID: segments[0]}, true
+		}
+	case "music.apple.com":
+		// Tracks are shared as album links selecting the song with ?i=
+		if id := u.Query().Get("i"); id != "" {
+			return Reference{Provider: "apple", ID: id}, true
+		}
+	}
+	return Reference{}, false
+}
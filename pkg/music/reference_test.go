@@ -0,0 +1,66 @@
+package music
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	const id = "4uLU6hMCjMI75M1A2tKUQC"
+	tests := []struct {
+		input string
+		want  Reference
+		ok    bool
+	}{
+		{"USUM71703861", Reference{ISRC: "USUM71703861"}, true},
+		{" us-um7-17-03861 ", Reference{ISRC: "USUM71703861"}, true},
+		{"USUM7170386", Reference{}, false},
+		{"1SUM71703861", Reference{}, false},
+		{"spotify:track:" + id, Reference{Provider: "spotify", ID: id}, true},
+		{"spotify:track:short", Reference{}, false},
+		{"spotify:album:" + id, Reference{}, false},
+		{"https://open.spotify.com/track/" + id + "?si=abc", Reference{Provider: "spotify", ID: id}, true},
+		{"http://open.spotify.com/track/" + id, Reference{Provider: "spotify", ID: id}, true},
+		{"https://open.spotify.com/intl-fr/track/" + id, Reference{Provider: "spotify", ID: id}, true},
+		{"https://open.spotify.com/album/" + id, Reference{}, false},
+		{"https://open.spotify.com/track/" + id + "/extra", Reference{}, false},
+		{"https://open.spotify.com/track/not%2Fan%2Fid%2Fat%2Fall", Reference{}, false},
+		{"https://evil.example/track/" + id, Reference{}, false},
+		{"ftp://open.spotify.com/track/" + id, Reference{}, false},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", Reference{Provider: "youtube", ID: "dQw4w9WgXcQ"}, true},
+		{"https://music.youtube.com/watch?v=dQw4w9WgXcQ&list=x", Reference{Provider: "youtube", ID: "dQw4w9WgXcQ"}, true},
+		{"https://www.youtube.com/feed", Reference{}, false},
+		{"https://youtu.be/dQw4w9WgXcQ", Reference{Provider: "youtube", ID: "dQw4w9WgXcQ"}, true},
+		{"https://youtu.be/", Reference{}, false},
+		{"https://music.apple.com/us/album/discovery/697194953?i=697195462", Reference{Provider: "apple", ID: "697195462"}, true},
+		{"https://music.apple.com/us/album/discovery/697194953", Reference{}, false},
+		{"daft punk", Reference{}, false},
+		{"", Reference{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ParseReference(tt.input)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseReference(%q) = %+v, %v, want %+v, %v", tt.input, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidSpotifyID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"4uLU6hMCjMI75M1A2tKUQC", true},
+		{"", false},
+		{"4uLU6hMCjMI75M1A2tKUQ", false},
+		{"4uLU6hMCjMI75M1A2tKUQCC", false},
+		{"4uLU6hMCjMI75M1A2t/UQC", false},
+		{"4uLU6hMCjMI75M1A2t,UQC", false},
+		{"4uLU6hMCjMI75M1A2t&UQC", false},
+		{"4uLU6hMCjMI75M1A2t%2FQ", false},
+	}
+	for _, tt := range tests {
+		if got := ValidSpotifyID(tt.id); got != tt.want {
+			t.Errorf("ValidSpotifyID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// This file will contain the search suggestions offered while the user is typing.
+
+package music
+
+// Suggestion is a track or artist name completing what the user has typed so far
+type Suggestion struct {
+	// Text is the name to show and search for
+	Text string `json:"text"`
+	// Type is "track" or "artist"
+	Type string `json:"type"`
+	// ID is the provider ID of the track or artist
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+}
@@ -0,0 +1,50 @@
+// This file will contain the provider-neutral music model returned by the JSON API.
+
+package music
+
+// Track is a track as exposed by the API, independently of the provider it comes from
+type Track struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Artists     []string `json:"artists"`
+	Album       string   `json:"album"`
+	AlbumArtURL string   `json:"album_art_url,omitempty"`
+	DurationMS  int      `json:"duration_ms"`
+	PreviewURL  string   `json:"preview_url,omitempty"`
+	ExternalURL string   `json:"external_url,omitempty"`
+	// Explicit is true when the lyrics are explicit
+	Explicit bool `json:"explicit"`
+	// Provider is the service the track comes from, e.g. "spotify"
+	Provider string `json:"provider"`
+}
+
+// Page describes where a list of results sits within the full result set
+type Page struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+	// NextOffset is the offset of the next page, nil on the last page
+	NextOffset *int `json:"next_offset"`
+}
+
+// NewPage builds the paging metadata of a page of count items starting at offset
+func NewPage(limit, offset, count, total int) Page {
+	p := Page{Limit: limit, Offset: offset, Total: total}
+	if next := offset + count; count > 0 && next < total {
+		p.NextOffset = &next
+	}
+	return p
+}
+
+// ValidMarket reports whether market looks like an ISO 3166-1 alpha-2 country code (e.g. "US")
+func ValidMarket(market string) bool {
+	if len(market) != 2 {
+		return false
+	}
+	for _, c := range market {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,13 @@
+// This file will describe the waveform of a track, which a player draws to show where the loud and quiet parts are.
+
+package music
+
+// Waveform summarises the loudness of a track's preview
+type Waveform struct {
+	TrackID string `json:"track_id"`
+	// Peaks are evenly spaced over the preview, from 0 (silence) to 1 (its loudest part)
+	Peaks []float64 `json:"peaks"`
+	// DurationMS is the length of the preview the peaks cover
+	DurationMS int    `json:"duration_ms"`
+	Provider   string `json:"provider"`
+}
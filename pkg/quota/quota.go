@@ -0,0 +1,214 @@
+// This file will track outbound requests per provider and enforce their quotas before we call out.
+
+package quota
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Limit describes the quota of a provider. Zero values mean "unlimited".
+type Limit struct {
+	// Daily is the number of requests allowed per UTC day
+	Daily int
+	// PerWindow is the number of requests allowed in each Window
+	PerWindow int
+	Window    time.Duration
+}
+
+// ExhaustedError is returned instead of calling a provider whose quota is used up
+type ExhaustedError struct {
+	Provider string
+	// RetryAfter is how long until the quota resets
+	RetryAfter time.Duration
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("%s quota exhausted, retry in %s", e.Provider, e.RetryAfter.Round(time.Second))
+}
+
+// Usage is a snapshot of the requests made to a provider, as shown on the admin endpoints
+type Usage struct {
+	Provider string `json:"provider"`
+	// Requests, Errors and Throttled count every call since the server started
+	Requests  int64 `json:"requests"`
+	Errors    int64 `json:"errors"`
+	Throttled int64 `json:"throttled"`
+	Rejected  int64 `json:"rejected"`
+	// AvgLatency is the mean duration of the calls that got a response
+	AvgLatency string `json:"avg_latency"`
+	// The remaining counts are -1 when the provider has no such limit
+	DailyUsed       int `json:"daily_used"`
+	DailyRemaining  int `json:"daily_remaining"`
+	WindowUsed      int `json:"window_used"`
+	WindowRemaining int `json:"window_remaining"`
+}
+
+// provider is the state kept for a single provider
+type provider struct {
+	limit Limit
+
+	requests, answered, errors, throttled, rejected int64
+	latency                                         time.Duration
+
+	day         time.Time
+	dayUsed     int
+	windowStart time.Time
+	windowUsed  int
+}
+
+// Manager counts outbound requests per provider and decides whether another request fits the quota.
+// It is safe for concurrent use.
+type Manager struct {
+	mu        sync.Mutex
+	providers map[string]*provider
+	now       func() time.Time
+}
+
+// NewManager returns a Manager enforcing the given limits, keyed by provider name
+func NewManager(limits map[string]Limit) *Manager {
+	m := &Manager{providers: make(map[string]*provider), now: time.Now}
+	for name, limit := range limits {
+		m.providers[name] = &provider{limit: limit}
+	}
+	return m
+}
+
+// Acquire reserves one request for the provider.
+// It returns an *ExhaustedError, without counting the request, when the quota is used up.
+func (m *Manager) Acquire(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.provider(name)
+	now := m.now()
+	p.reset(now)
+
+	if p.limit.Daily > 0 && p.dayUsed >= p.limit.Daily {
+		p.rejected++
+		return &ExhaustedError{Provider: name, RetryAfter: p.day.AddDate(0, 0, 1).Sub(now)}
+	}
+	if p.limit.PerWindow > 0 && p.limit.Window > 0 && p.windowUsed >= p.limit.PerWindow {
+		p.rejected++
+		return &ExhaustedError{Provider: name, RetryAfter: p.windowStart.Add(p.limit.Window).Sub(now)}
+	}
+
+	p.dayUsed++
+	p.windowUsed++
+	p.requests++
+	return nil
+}
+
+// Record stores the outcome of a request reserved with Acquire.
+// A nil response means the request failed before the provider answered.
+func (m *Manager) Record(name string, resp *http.Response, err error, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.provider(name)
+	if err != nil || resp == nil {
+		p.errors++
+		return
+	}
+
+	p.answered++
+	p.latency += latency
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		p.throttled++
+	case resp.StatusCode >= 500:
+		p.errors++
+	}
+}
+
+// Usage returns a snapshot of every provider, sorted by name
+func (m *Manager) Usage() []Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	usage := make([]Usage, 0, len(m.providers))
+	for name, p := range m.providers {
+		p.reset(now)
+		u := Usage{
+			Provider:        name,
+			Requests:        p.requests,
+			Errors:          p.errors,
+			Throttled:       p.throttled,
+			Rejected:        p.rejected,
+			DailyUsed:       p.dayUsed,
+			DailyRemaining:  remaining(p.limit.Daily, p.dayUsed),
+			WindowUsed:      p.windowUsed,
+			WindowRemaining: remaining(p.limit.PerWindow, p.windowUsed),
+		}
+		if p.answered > 0 {
+			u.AvgLatency = (p.latency / time.Duration(p.answered)).Round(time.Millisecond).String()
+		}
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Provider < usage[j].Provider })
+	return usage
+}
+
+// provider returns the state of name, creating an unlimited one if needed.
+// The caller must hold m.mu.
+func (m *Manager) provider(name string) *provider {
+	p, ok := m.providers[name]
+	if !ok {
+		p = &provider{}
+		m.providers[name] = p
+	}
+	return p
+}
+
+// reset starts a new day or window when the current one is over
+func (p *provider) reset(now time.Time) {
+	if day := now.UTC().Truncate(24 * time.Hour); !day.Equal(p.day) {
+		p.day = day
+		p.dayUsed = 0
+	}
+	if p.limit.Window > 0 && !now.Before(p.windowStart.Add(p.limit.Window)) {
+		p.windowStart = now
+		p.windowUsed = 0
+	}
+}
+
+// remaining returns how many requests are left, or -1 when there is no limit
+func remaining(limit, used int) int {
+	if limit <= 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// Transport is an http.RoundTripper that checks the quota of Provider before every request
+// and records the outcome afterwards.
+type Transport struct {
+	Provider string
+	Manager  *Manager
+	// Base performs the actual requests; http.DefaultTransport is used when nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Manager.Acquire(t.Provider); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	t.Manager.Record(t.Provider, resp, err, time.Since(start))
+	return resp, err
+}
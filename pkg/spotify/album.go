@@ -0,0 +1,82 @@
+// This file will contain the album details: the album with its whole tracklist.
+
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+
+	"Smart-Music-Go/pkg/music"
+
+	"github.com/zmb3/spotify/v2"
+)
+
+// maxAlbumTrackPages bounds the pages of tracks fetched for an album, Spotify returns 50 tracks per page.
+// Only the largest box sets go beyond it.
+const maxAlbumTrackPages = 20
+
+// Album returns the album with the given Spotify ID and its complete tracklist.
+// When market is not empty, tracks unavailable in that country are relinked to an available version when one exists.
+// Albums are cached with the search results and concurrent identical requests share the API calls.
+func (sc *SpotifyClient) Album(ctx context.Context, id, market string) (music.AlbumDetails, error) {
+	key := "album:" + market + ":" + id
+	if cached, ok := sc.cachedAlbum(ctx, key); ok {
+		return cached, nil
+	}
+
+	v, err := sc.shared(ctx, key, func(ctx context.Context) (interface{}, error) {
+		album, err := sc.album(ctx, id, market)
+		if err == nil {
+			sc.cacheAlbum(ctx, key, album)
+		}
+		return album, err
+	})
+	if err != nil {
+		return music.AlbumDetails{}, err
+	}
+	return v.(music.AlbumDetails), nil
+}
+
+// album fetches an album and follows the pages of its tracks, without caching
+func (sc *SpotifyClient) album(ctx context.Context, id, market string) (music.AlbumDetails, error) {
+	album, err := sc.Client.GetAlbum(ctx, spotify.ID(id), marketOptions(market)...)
+	if err != nil {
+		return music.AlbumDetails{}, err
+	}
+
+	tracks := album.Tracks.Tracks
+	page := album.Tracks
+	for i := 1; page.Next != "" && i < maxAlbumTrackPages; i++ {
+		if err := sc.Client.NextPage(ctx, &page); err != nil {
+			return music.AlbumDetails{}, err
+		}
+		tracks = append(tracks, page.Tracks...)
+	}
+	return ToAlbumDetails(*album, tracks), nil
+}
+
+// cachedAlbum returns a cached album, treating cache failures as misses
+func (sc *SpotifyClient) cachedAlbum(ctx context.Context, key string) (music.AlbumDetails, bool) {
+	if sc.searches == nil {
+		return music.AlbumDetails{}, false
+	}
+	data, ok, err := sc.searches.Get(ctx, key)
+	if err != nil || !ok {
+		return music.AlbumDetails{}, false
+	}
+	var album music.AlbumDetails
+	if err := json.Unmarshal(data, &album); err != nil {
+		return music.AlbumDetails{}, false
+	}
+	return album, true
+}
+
+// cacheAlbum stores an album, ignoring cache failures
+func (sc *SpotifyClient) cacheAlbum(ctx context.Context, key string, album music.AlbumDetails) {
+	if sc.searches == nil {
+		return
+	}
+	if data, err := json.Marshal(album); err == nil {
+		_ = sc.searches.Set(ctx, key, data)
+	}
+}
@@ -0,0 +1,154 @@
+// This file will contain the artist details: the artist with their top tracks, discography and related artists.
+
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"Smart-Music-Go/pkg/music"
+
+	"github.com/zmb3/spotify/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// topTracksMarket is the country whose top tracks are listed when the request doesn't name one,
+	// as Spotify ranks them per country
+	topTracksMarket = "US"
+	// maxArtistAlbumPages bounds the pages of the discography fetched for an artist, Spotify returns 50 albums per page
+	maxArtistAlbumPages = 4
+)
+
+// Artist returns the artist with the given Spotify ID, with their top tracks in market, discography and related artists.
+// The calls run concurrently. Artists are cached with the search results and concurrent identical requests share the API calls.
+func (sc *SpotifyClient) Artist(ctx context.Context, id, market string) (music.ArtistDetails, error) {
+	key := "artist:" + market + ":" + id
+	if cached, ok := sc.cachedArtist(ctx, key); ok {
+		return cached, nil
+	}
+
+	v, err := sc.shared(ctx, key, func(ctx context.Context) (interface{}, error) {
+		artist, err := sc.artist(ctx, id, market)
+		if err == nil {
+			sc.cacheArtist(ctx, key, artist)
+		}
+		return artist, err
+	})
+	if err != nil {
+		return music.ArtistDetails{}, err
+	}
+	return v.(music.ArtistDetails), nil
+}
+
+// artist fetches an artist and their discography, without caching
+func (sc *SpotifyClient) artist(ctx context.Context, id, market string) (music.ArtistDetails, error) {
+	var (
+		artist  *spotify.FullArtist
+		top     []spotify.FullTrack
+		albums  []spotify.SimpleAlbum
+		related []spotify.FullArtist
+	)
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		artist, err = sc.Client.GetArtist(ctx, spotify.ID(id))
+		return err
+	})
+	g.Go(func() (err error) {
+		country := market
+		if country == "" {
+			country = topTracksMarket
+		}
+		top, err = sc.Client.GetArtistsTopTracks(ctx, spotify.ID(id), country)
+		return err
+	})
+	g.Go(func() (err error) {
+		albums, err = sc.artistAlbums(ctx, id, market)
+		return err
+	})
+	g.Go(func() error {
+		// Spotify doesn't serve related artists to applications created since November 2024,
+		// so they are left out rather than failing the whole request.
+		// That refusal is expected and only logged at debug level, other failures are worth a warning.
+		var err error
+		related, err = sc.Client.GetRelatedArtists(ctx, spotify.ID(id))
+		var apiErr spotify.Error
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden:
+			sc.logger().Debug("related artists refused", "artist", id, "err", err)
+		case err != nil && ctx.Err() == nil:
+			sc.logger().Warn("fetching related artists failed", "artist", id, "err", err)
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return music.ArtistDetails{}, err
+	}
+
+	details := music.ArtistDetails{
+		Artist:         ToArtist(*artist),
+		Followers:      int(artist.Followers.Count),
+		Popularity:     int(artist.Popularity),
+		TopTracks:      make([]music.Track, 0, len(top)),
+		Albums:         make([]music.Album, 0, len(albums)),
+		RelatedArtists: make([]music.Artist, 0, len(related)),
+	}
+	for _, t := range top {
+		details.TopTracks = append(details.TopTracks, ToTrack(t))
+	}
+	for _, a := range albums {
+		details.Albums = append(details.Albums, ToAlbum(a))
+	}
+	for _, a := range related {
+		details.RelatedArtists = append(details.RelatedArtists, ToArtist(a))
+	}
+	return details, nil
+}
+
+// artistAlbums returns the albums, singles and compilations of an artist, following the pages of the discography.
+// Without a market Spotify lists an album once for every country it was released in, so market is worth giving.
+func (sc *SpotifyClient) artistAlbums(ctx context.Context, id, market string) ([]spotify.SimpleAlbum, error) {
+	types := []spotify.AlbumType{spotify.AlbumTypeAlbum, spotify.AlbumTypeSingle, spotify.AlbumTypeCompilation}
+	opts := append([]spotify.RequestOption{spotify.Limit(50)}, marketOptions(market)...)
+	page, err := sc.Client.GetArtistAlbums(ctx, spotify.ID(id), types, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := page.Albums
+	for i := 1; page.Next != "" && i < maxArtistAlbumPages; i++ {
+		if err := sc.Client.NextPage(ctx, page); err != nil {
+			return nil, err
+		}
+		albums = append(albums, page.Albums...)
+	}
+	return albums, nil
+}
+
+// cachedArtist returns a cached artist, treating cache failures as misses
+func (sc *SpotifyClient) cachedArtist(ctx context.Context, key string) (music.ArtistDetails, bool) {
+	if sc.searches == nil {
+		return music.ArtistDetails{}, false
+	}
+	data, ok, err := sc.searches.Get(ctx, key)
+	if err != nil || !ok {
+		return music.ArtistDetails{}, false
+	}
+	var artist music.ArtistDetails
+	if err := json.Unmarshal(data, &artist); err != nil {
+		return music.ArtistDetails{}, false
+	}
+	return artist, true
+}
+
+// cacheArtist stores an artist, ignoring cache failures
+func (sc *SpotifyClient) cacheArtist(ctx context.Context, key string, artist music.ArtistDetails) {
+	if sc.searches == nil {
+		return
+	}
+	if data, err := json.Marshal(artist); err == nil {
+		_ = sc.searches.Set(ctx, key, data)
+	}
+}
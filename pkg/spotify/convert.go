@@ -0,0 +1,129 @@
+// This file will convert Spotify API objects into the provider-neutral music model.
+
+package spotify
+
+import (
+	"Smart-Music-Go/pkg/music"
+
+	"github.com/zmb3/spotify/v2"
+)
+
+// ToTrack converts a Spotify track into a music.Track
+func ToTrack(t spotify.FullTrack) music.Track {
+	track := music.Track{
+		ID:          string(t.ID),
+		Name:        t.Name,
+		Artists:     make([]string, 0, len(t.Artists)),
+		Album:       t.Album.Name,
+		DurationMS:  int(t.Duration),
+		PreviewURL:  t.PreviewURL,
+		ExternalURL: t.ExternalURLs["spotify"],
+		Explicit:    t.Explicit,
+		Provider:    "spotify",
+	}
+	for _, a := range t.Artists {
+		track.Artists = append(track.Artists, a.Name)
+	}
+	// Spotify lists the album images from largest to smallest
+	if len(t.Album.Images) > 0 {
+		track.AlbumArtURL = t.Album.Images[0].URL
+	}
+	return track
+}
+
+// ToArtist converts a Spotify artist into a music.Artist
+func ToArtist(a spotify.FullArtist) music.Artist {
+	artist := music.Artist{
+		ID:          string(a.ID),
+		Name:        a.Name,
+		Genres:      a.Genres,
+		ExternalURL: a.ExternalURLs["spotify"],
+		Provider:    "spotify",
+	}
+	if artist.Genres == nil {
+		artist.Genres = []string{}
+	}
+	if len(a.Images) > 0 {
+		artist.ImageURL = a.Images[0].URL
+	}
+	return artist
+}
+
+// ToAlbum converts a Spotify album into a music.Album
+func ToAlbum(a spotify.SimpleAlbum) music.Album {
+	album := music.Album{
+		ID:          string(a.ID),
+		Name:        a.Name,
+		Artists:     make([]string, 0, len(a.Artists)),
+		Type:        a.AlbumType,
+		ReleaseDate: a.ReleaseDate,
+		TotalTracks: int(a.TotalTracks),
+		ExternalURL: a.ExternalURLs["spotify"],
+		Provider:    "spotify",
+	}
+	for _, artist := range a.Artists {
+		album.Artists = append(album.Artists, artist.Name)
+	}
+	if len(a.Images) > 0 {
+		album.ImageURL = a.Images[0].URL
+	}
+	return album
+}
+
+// ToAlbumDetails converts a Spotify album and its tracks into a music.AlbumDetails.
+// The tracks are passed apart from the album, which only embeds the first page of them.
+func ToAlbumDetails(a spotify.FullAlbum, tracks []spotify.SimpleTrack) music.AlbumDetails {
+	album := music.AlbumDetails{
+		Album:      ToAlbum(a.SimpleAlbum),
+		Genres:     a.Genres,
+		Copyrights: make([]string, 0, len(a.Copyrights)),
+		UPC:        a.ExternalIDs["upc"],
+		Popularity: int(a.Popularity),
+		Tracks:     make([]music.AlbumTrack, 0, len(tracks)),
+	}
+	if album.Genres == nil {
+		album.Genres = []string{}
+	}
+	for _, c := range a.Copyrights {
+		album.Copyrights = append(album.Copyrights, c.Text)
+	}
+	for _, t := range tracks {
+		track := music.AlbumTrack{
+			Track: music.Track{
+				ID:          string(t.ID),
+				Name:        t.Name,
+				Artists:     make([]string, 0, len(t.Artists)),
+				Album:       a.Name,
+				AlbumArtURL: album.ImageURL,
+				DurationMS:  int(t.Duration),
+				PreviewURL:  t.PreviewURL,
+				ExternalURL: t.ExternalURLs["spotify"],
+				Explicit:    t.Explicit,
+				Provider:    "spotify",
+			},
+			DiscNumber:  int(t.DiscNumber),
+			TrackNumber: int(t.TrackNumber),
+		}
+		for _, artist := range t.Artists {
+			track.Artists = append(track.Artists, artist.Name)
+		}
+		album.Tracks = append(album.Tracks, track)
+	}
+	return album
+}
+
+// ToPlaylist converts a Spotify playlist into a music.Playlist
+func ToPlaylist(p spotify.SimplePlaylist) music.Playlist {
+	playlist := music.Playlist{
+		ID:          string(p.ID),
+		Name:        p.Name,
+		Owner:       p.Owner.DisplayName,
+		TotalTracks: int(p.Tracks.Total),
+		ExternalURL: p.ExternalURLs["spotify"],
+		Provider:    "spotify",
+	}
+	if len(p.Images) > 0 {
+		playlist.ImageURL = p.Images[0].URL
+	}
+	return playlist
+}
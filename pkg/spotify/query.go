@@ -0,0 +1,39 @@
+// This file will contain the translation of parsed search queries to the Spotify search syntax.
+
+package spotify
+
+import (
+	"fmt"
+	"strings"
+
+	"Smart-Music-Go/pkg/music"
+)
+
+// searchQuery writes q with Spotify's field filters.
+// See https://developer.spotify.com/documentation/web-api/reference/search
+func searchQuery(q music.Query) string {
+	parts := []string{}
+	if q.Text != "" {
+		parts = append(parts, q.Text)
+	}
+	filter := func(field, value string) {
+		if value == "" {
+			return
+		}
+		if strings.ContainsAny(value, " \t") {
+			value = `"` + value + `"`
+		}
+		parts = append(parts, field+":"+value)
+	}
+	filter("artist", q.Artist)
+	filter("track", q.Track)
+	filter("album", q.Album)
+	filter("genre", q.Genre)
+	filter("isrc", q.ISRC)
+	if q.YearFrom == q.YearTo && q.YearFrom != 0 {
+		filter("year", fmt.Sprint(q.YearFrom))
+	} else if q.YearFrom != 0 {
+		filter("year", fmt.Sprintf("%d-%d", q.YearFrom, q.YearTo))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,103 @@
+// This file will contain the search returning tracks, artists, albums and playlists at once.
+
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"Smart-Music-Go/pkg/music"
+
+	"github.com/zmb3/spotify/v2"
+)
+
+// SearchAll returns up to limit tracks, artists, albums and playlists matching query, in a single API call.
+// Results are cached like the other searches.
+func (sc *SpotifyClient) SearchAll(ctx context.Context, query music.Query, market string, limit int) (music.SearchResults, error) {
+	q := searchQuery(query)
+	key := fmt.Sprintf("all:%s:%d:%s", market, limit, strings.ToLower(q))
+	if cached, ok := sc.cachedSearchAll(ctx, key); ok {
+		return cached, nil
+	}
+
+	v, err := sc.shared(ctx, key, func(ctx context.Context) (interface{}, error) {
+		results, err := sc.searchAll(ctx, q, market, limit)
+		if err == nil {
+			sc.cacheSearchAll(ctx, key, results)
+		}
+		return results, err
+	})
+	if err != nil {
+		return music.SearchResults{}, err
+	}
+	return v.(music.SearchResults), nil
+}
+
+// searchAll performs the search on Spotify, without caching
+func (sc *SpotifyClient) searchAll(ctx context.Context, query, market string, limit int) (music.SearchResults, error) {
+	types := spotify.SearchTypeTrack | spotify.SearchTypeArtist | spotify.SearchTypeAlbum | spotify.SearchTypePlaylist
+	opts := append([]spotify.RequestOption{spotify.Limit(limit)}, marketOptions(market)...)
+	found, err := sc.Client.Search(ctx, query, types, opts...)
+	if err != nil {
+		return music.SearchResults{}, err
+	}
+
+	results := music.SearchResults{
+		Tracks:    []music.Track{},
+		Artists:   []music.Artist{},
+		Albums:    []music.Album{},
+		Playlists: []music.Playlist{},
+	}
+	if found.Tracks != nil {
+		for _, t := range found.Tracks.Tracks {
+			results.Tracks = append(results.Tracks, ToTrack(t))
+		}
+	}
+	if found.Artists != nil {
+		for _, a := range found.Artists.Artists {
+			results.Artists = append(results.Artists, ToArtist(a))
+		}
+	}
+	if found.Albums != nil {
+		for _, a := range found.Albums.Albums {
+			results.Albums = append(results.Albums, ToAlbum(a))
+		}
+	}
+	if found.Playlists != nil {
+		for _, p := range found.Playlists.Playlists {
+			// Spotify returns null for playlists it has removed since indexing them
+			if p.ID != "" {
+				results.Playlists = append(results.Playlists, ToPlaylist(p))
+			}
+		}
+	}
+	return results, nil
+}
+
+// cachedSearchAll returns cached results of SearchAll, treating cache failures as misses
+func (sc *SpotifyClient) cachedSearchAll(ctx context.Context, key string) (music.SearchResults, bool) {
+	if sc.searches == nil {
+		return music.SearchResults{}, false
+	}
+	data, ok, err := sc.searches.Get(ctx, key)
+	if err != nil || !ok {
+		return music.SearchResults{}, false
+	}
+	var results music.SearchResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return music.SearchResults{}, false
+	}
+	return results, true
+}
+
+// cacheSearchAll stores the results of SearchAll, ignoring cache failures
+func (sc *SpotifyClient) cacheSearchAll(ctx context.Context, key string, results music.SearchResults) {
+	if sc.searches == nil {
+		return
+	}
+	if data, err := json.Marshal(results); err == nil {
+		_ = sc.searches.Set(ctx, key, data)
+	}
+}
@@ -1,48 +1,377 @@
-// This file will contain the code to interact with the Spotify API. 
-
-package spotify
-
-import (
-	"context"
-	"github.com/zmb3/spotify"
-	"golang.org/x/oauth2/clientcredentials"
-)
-
-// SpotifyClient is a wrapper around the Spotify API client
-type SpotifyClient struct {
-	Client spotify.Client
-}
-
-// NewSpotifyClient creates a new Spotify API client with client credentials
-func NewSpotifyClient(clientID string, clientSecret string) SpotifyClient {
-	config := &clientcredentials.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		TokenURL:     spotify.TokenURL,
-	}
-
-	token, err := config.Token(context.Background())
-	if err != nil {
-		panic(err)
-	}
-
-	client := spotify.Authenticator{}.NewClient(token)
-	return SpotifyClient{Client: client}
-}
-
-// SearchTrack searches for a track on Spotify
-// This function performs a search on Spotify for the given track and returns the first result. 
-// If no tracks are found, it returns an error.
-func (sc *SpotifyClient) SearchTrack(track string) (spotify.FullTrack, error) {
-	results, err := sc.Client.Search(track, spotify.SearchTypeTrack)
-	if err != nil {
-		return spotify.FullTrack{}, err
-	}
-
-	if results.Tracks != nil && len(results.Tracks.Tracks) > 0 {
-		return results.Tracks.Tracks[0], nil
-	}
-
-	return spotify.FullTrack{}, fmt.Errorf("no tracks found")
-}
-
+// This file will contain the code to interact with the Spotify API.
+
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"Smart-Music-Go/pkg/cache"
+	"Smart-Music-Go/pkg/logging"
+	"Smart-Music-Go/pkg/music"
+
+	"github.com/zmb3/spotify/v2"
+	spotifyauth "github.com/zmb3/spotify/v2/auth"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/sync/singleflight"
+)
+
+// SpotifyClient is a wrapper around the Spotify API client
+type SpotifyClient struct {
+	Client *spotify.Client
+	// Logger receives the failures that don't fail a request, such as missing related artists; when nil, they are discarded
+	Logger *slog.Logger
+
+	// credentials is kept to check the client ID and secret independently of the cached token
+	credentials *clientcredentials.Config
+
+	// searches caches search results when EnableSearchCache was called, nil otherwise
+	searches cache.Store
+	// inflight merges identical searches running at the same time into a single API call, see shared
+	inflight singleflight.Group
+	// analyses caches track analyses when EnableAnalysisCache was called, nil otherwise
+	analyses cache.Store
+}
+
+// logger returns the client logger, or one that discards everything when none was set
+func (sc *SpotifyClient) logger() *slog.Logger {
+	if sc.Logger == nil {
+		return logging.Discard()
+	}
+	return sc.Logger
+}
+
+// NewSpotifyClient creates a new Spotify API client with client credentials
+// The token is fetched on the first request and refreshed automatically when it expires,
+// so a single client can be shared by the whole application.
+// API calls go through base, which may be nil to use http.DefaultTransport.
+func NewSpotifyClient(clientID string, clientSecret string, base http.RoundTripper) *SpotifyClient {
+	config := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     spotifyauth.TokenURL,
+	}
+
+	httpClient := &http.Client{
+		Transport: &oauth2.Transport{Source: config.TokenSource(context.Background()), Base: base},
+		Timeout:   apiTimeout,
+	}
+	client := spotify.New(httpClient)
+	return &SpotifyClient{Client: client, credentials: config}
+}
+
+// apiTimeout bounds every call to the Spotify API, and the calls shared by concurrent requests as a whole
+const apiTimeout = 15 * time.Second
+
+// shared runs fn once for all the callers asking for key at the same time and returns its result to each of them.
+// fn runs on a context detached from the callers, bounded by apiTimeout, so a caller going away doesn't fail the others.
+// Each caller stops waiting as soon as its own ctx is done.
+func (sc *SpotifyClient) shared(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := sc.inflight.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), apiTimeout)
+		defer cancel()
+		return fn(ctx)
+	})
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// EnableSearchCache keeps search results in store.
+// The client only uses app credentials, so results are the same for every user and safe to share.
+func (sc *SpotifyClient) EnableSearchCache(store cache.Store) {
+	sc.searches = store
+}
+
+// CheckCredentials requests a fresh token from Spotify to verify that the client ID and secret are accepted.
+// It is meant for health checks and does not touch the token used by the client.
+func (sc *SpotifyClient) CheckCredentials(ctx context.Context) error {
+	if sc.credentials == nil {
+		return fmt.Errorf("spotify client has no credentials")
+	}
+	_, err := sc.credentials.Token(ctx)
+	return err
+}
+
+// EnableAnalysisCache keeps track analyses in store.
+// The audio features of a track never change, so the store can keep them for a long time.
+func (sc *SpotifyClient) EnableAnalysisCache(store cache.Store) {
+	sc.analyses = store
+}
+
+// SearchTrack searches for a track on Spotify
+// This function performs a search on Spotify for the given query and returns the first result.
+// The filters of the query are translated to Spotify field filters.
+// If no tracks are found, it returns an error.
+// When market (an ISO 3166-1 alpha-2 country code) is not empty, only tracks available in that country are returned.
+// Results are served from the search cache when it is enabled, and concurrent identical
+// searches share a single call to Spotify.
+func (sc *SpotifyClient) SearchTrack(ctx context.Context, query music.Query, market string) (spotify.FullTrack, error) {
+	track := searchQuery(query)
+	key := market + ":" + strings.ToLower(track)
+	if cached, ok := sc.cachedSearch(ctx, key); ok {
+		return cached, nil
+	}
+
+	v, err := sc.shared(ctx, key, func(ctx context.Context) (interface{}, error) {
+		result, err := sc.searchTrack(ctx, track, market)
+		if err == nil {
+			sc.cacheSearch(ctx, key, result)
+		}
+		return result, err
+	})
+	if err != nil {
+		return spotify.FullTrack{}, err
+	}
+	return v.(spotify.FullTrack), nil
+}
+
+// cachedSearch returns the cached result of a search.
+// A failing cache is treated as a miss: it only costs an extra API call.
+func (sc *SpotifyClient) cachedSearch(ctx context.Context, key string) (spotify.FullTrack, bool) {
+	if sc.searches == nil {
+		return spotify.FullTrack{}, false
+	}
+	data, ok, err := sc.searches.Get(ctx, key)
+	if err != nil || !ok {
+		return spotify.FullTrack{}, false
+	}
+	var track spotify.FullTrack
+	if err := json.Unmarshal(data, &track); err != nil {
+		return spotify.FullTrack{}, false
+	}
+	return track, true
+}
+
+// cacheSearch stores the result of a search, ignoring cache failures
+func (sc *SpotifyClient) cacheSearch(ctx context.Context, key string, track spotify.FullTrack) {
+	if sc.searches == nil {
+		return
+	}
+	if data, err := json.Marshal(track); err == nil {
+		_ = sc.searches.Set(ctx, key, data)
+	}
+}
+
+// searchTrack performs the search on Spotify, without caching
+func (sc *SpotifyClient) searchTrack(ctx context.Context, track, market string) (spotify.FullTrack, error) {
+	results, err := sc.Client.Search(ctx, track, spotify.SearchTypeTrack, marketOptions(market)...)
+	if err != nil {
+		return spotify.FullTrack{}, err
+	}
+
+	if results.Tracks != nil && len(results.Tracks.Tracks) > 0 {
+		return results.Tracks.Tracks[0], nil
+	}
+
+	return spotify.FullTrack{}, fmt.Errorf("no tracks found")
+}
+
+// maxSearchOffset is the deepest Spotify lets a search be paged: offset plus limit can't exceed it
+const maxSearchOffset = 1000
+
+// trackPage is a page of tracks along with the size of the whole result set, as kept in the search cache
+type trackPage struct {
+	Tracks []music.Track `json:"tracks"`
+	Total  int           `json:"total"`
+}
+
+// SearchTracks returns up to limit tracks matching query, starting at offset,
+// along with the number of results that can be paged through.
+// Pages are cached like the results of SearchTrack.
+func (sc *SpotifyClient) SearchTracks(ctx context.Context, query music.Query, market string, limit, offset int) ([]music.Track, int, error) {
+	q := searchQuery(query)
+	key := fmt.Sprintf("tracks:%s:%d:%d:%s", market, limit, offset, strings.ToLower(q))
+	if cached, ok := sc.cachedTrackPage(ctx, key); ok {
+		return cached.Tracks, cached.Total, nil
+	}
+
+	v, err := sc.shared(ctx, key, func(ctx context.Context) (interface{}, error) {
+		page, err := sc.searchTracks(ctx, q, market, limit, offset)
+		if err == nil {
+			sc.cacheTrackPage(ctx, key, page)
+		}
+		return page, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page := v.(trackPage)
+	return page.Tracks, page.Total, nil
+}
+
+// searchTracks performs a paged track search on Spotify, without caching
+func (sc *SpotifyClient) searchTracks(ctx context.Context, query, market string, limit, offset int) (trackPage, error) {
+	opts := append([]spotify.RequestOption{spotify.Limit(limit), spotify.Offset(offset)}, marketOptions(market)...)
+	results, err := sc.Client.Search(ctx, query, spotify.SearchTypeTrack, opts...)
+	if err != nil {
+		return trackPage{}, err
+	}
+
+	page := trackPage{Tracks: []music.Track{}}
+	if results.Tracks != nil {
+		for _, t := range results.Tracks.Tracks {
+			page.Tracks = append(page.Tracks, ToTrack(t))
+		}
+		// Results past maxSearchOffset exist but can't be fetched, so don't offer them
+		page.Total = int(results.Tracks.Total)
+		if page.Total > maxSearchOffset {
+			page.Total = maxSearchOffset
+		}
+	}
+	return page, nil
+}
+
+// cachedTrackPage returns a cached page of search results, treating cache failures as misses
+func (sc *SpotifyClient) cachedTrackPage(ctx context.Context, key string) (trackPage, bool) {
+	if sc.searches == nil {
+		return trackPage{}, false
+	}
+	data, ok, err := sc.searches.Get(ctx, key)
+	if err != nil || !ok {
+		return trackPage{}, false
+	}
+	var page trackPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return trackPage{}, false
+	}
+	return page, true
+}
+
+// cacheTrackPage stores a page of search results, ignoring cache failures
+func (sc *SpotifyClient) cacheTrackPage(ctx context.Context, key string, page trackPage) {
+	if sc.searches == nil {
+		return
+	}
+	if data, err := json.Marshal(page); err == nil {
+		_ = sc.searches.Set(ctx, key, data)
+	}
+}
+
+// Track returns the track with the given Spotify ID.
+// When market is not empty and the track isn't available there, an available version is returned if there is one.
+func (sc *SpotifyClient) Track(ctx context.Context, id, market string) (spotify.FullTrack, error) {
+	track, err := sc.Client.GetTrack(ctx, spotify.ID(id), marketOptions(market)...)
+	if err != nil {
+		return spotify.FullTrack{}, err
+	}
+	return *track, nil
+}
+
+// marketOptions returns the request option restricting results to market, if there is one
+func marketOptions(market string) []spotify.RequestOption {
+	if market == "" {
+		return nil
+	}
+	return []spotify.RequestOption{spotify.Market(market)}
+}
+
+// PlaylistTracks returns the tracks among up to limit items of a public playlist, starting at offset,
+// along with the number of items read, which is where the next page starts, and the total number of items in the playlist.
+// Private playlists can't be read with the app credentials and are reported as not found by Spotify.
+// Podcast episodes and items unavailable in market are skipped, so there can be fewer tracks than items.
+// When market is not empty, tracks unavailable in that country are relinked to an available version when one exists.
+func (sc *SpotifyClient) PlaylistTracks(ctx context.Context, playlistID, market string, limit, offset int) (tracks []music.Track, items, total int, err error) {
+	opts := append([]spotify.RequestOption{spotify.Limit(limit), spotify.Offset(offset)}, marketOptions(market)...)
+	page, err := sc.Client.GetPlaylistItems(ctx, spotify.ID(playlistID), opts...)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	tracks = make([]music.Track, 0, len(page.Items))
+	for _, item := range page.Items {
+		if item.Track.Track != nil {
+			tracks = append(tracks, ToTrack(*item.Track.Track))
+		}
+	}
+	return tracks, len(page.Items), int(page.Total), nil
+}
+
+// ErrAnalysisUnavailable is returned by TrackAnalyses when Spotify refuses the audio features API to this application,
+// as it does for applications created since November 2024
+var ErrAnalysisUnavailable = errors.New("spotify: audio features are not available to this application")
+
+// maxAudioFeaturesIDs is the number of tracks Spotify accepts in a single audio features request
+const maxAudioFeaturesIDs = 100
+
+// TrackAnalyses returns the tempo, key and time signature of the given tracks, keyed by track ID.
+// Cached analyses are reused, and the others are fetched from the audio features API in batches.
+// Tracks Spotify knows nothing about are missing from the result.
+func (sc *SpotifyClient) TrackAnalyses(ctx context.Context, ids []string) (map[string]music.Analysis, error) {
+	analyses := make(map[string]music.Analysis, len(ids))
+	var missing []spotify.ID
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if a, ok := sc.cachedAnalysis(ctx, id); ok {
+			analyses[id] = a
+			continue
+		}
+		missing = append(missing, spotify.ID(id))
+	}
+
+	for start := 0; start < len(missing); start += maxAudioFeaturesIDs {
+		end := start + maxAudioFeaturesIDs
+		if end > len(missing) {
+			end = len(missing)
+		}
+		features, err := sc.Client.GetAudioFeatures(ctx, missing[start:end]...)
+		var apiErr spotify.Error
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden {
+			return nil, ErrAnalysisUnavailable
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range features {
+			// Unknown IDs come back as null
+			if f == nil {
+				continue
+			}
+			a := music.NewAnalysis(string(f.ID), float64(f.Tempo), int(f.Key), int(f.Mode), int(f.TimeSignature), "spotify")
+			a.Energy = float64(f.Energy)
+			analyses[a.TrackID] = a
+			sc.cacheAnalysis(ctx, a)
+		}
+	}
+	return analyses, nil
+}
+
+// cachedAnalysis returns the cached analysis of a track, treating cache failures as misses
+func (sc *SpotifyClient) cachedAnalysis(ctx context.Context, id string) (music.Analysis, bool) {
+	if sc.analyses == nil {
+		return music.Analysis{}, false
+	}
+	data, ok, err := sc.analyses.Get(ctx, id)
+	if err != nil || !ok {
+		return music.Analysis{}, false
+	}
+	var a music.Analysis
+	if err := json.Unmarshal(data, &a); err != nil {
+		return music.Analysis{}, false
+	}
+	return a, true
+}
+
+// cacheAnalysis stores the analysis of a track, ignoring cache failures
+func (sc *SpotifyClient) cacheAnalysis(ctx context.Context, a music.Analysis) {
+	if sc.analyses == nil {
+		return
+	}
+	if data, err := json.Marshal(a); err == nil {
+		_ = sc.analyses.Set(ctx, a.TrackID, data)
+	}
+}
@@ -0,0 +1,107 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSharedCallerCancellation(t *testing.T) {
+	tests := []struct {
+		name string
+		// cancelFirst cancels the caller that started the shared call, cancelSecond the one that joined it
+		cancelFirst, cancelSecond bool
+	}{
+		{name: "no caller cancels"},
+		{name: "first caller cancels", cancelFirst: true},
+		{name: "joining caller cancels", cancelSecond: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &SpotifyClient{}
+			started := make(chan struct{})
+			release := make(chan struct{})
+			var calls int32
+			fn := func(ctx context.Context) (interface{}, error) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					close(started)
+				}
+				<-release
+				// The shared call must outlive the callers that went away
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, errors.New("shared call has no deadline")
+				}
+				return "result", nil
+			}
+
+			firstCtx, cancelFirst := context.WithCancel(context.Background())
+			defer cancelFirst()
+			secondCtx, cancelSecond := context.WithCancel(context.Background())
+			defer cancelSecond()
+
+			type outcome struct {
+				v   interface{}
+				err error
+			}
+			first := make(chan outcome, 1)
+			second := make(chan outcome, 1)
+			go func() {
+				v, err := sc.shared(firstCtx, "key", fn)
+				first <- outcome{v, err}
+			}()
+			<-started
+			go func() {
+				v, err := sc.shared(secondCtx, "key", fn)
+				second <- outcome{v, err}
+			}()
+			// Give the second caller time to join the running call
+			time.Sleep(50 * time.Millisecond)
+
+			wait := func(ch chan outcome) outcome {
+				select {
+				case o := <-ch:
+					return o
+				case <-time.After(5 * time.Second):
+					t.Fatal("caller still waiting")
+					return outcome{}
+				}
+			}
+			check := func(name string, o outcome, cancelled bool) {
+				if cancelled {
+					if !errors.Is(o.err, context.Canceled) {
+						t.Errorf("%s caller: got %v, %v, want context.Canceled", name, o.v, o.err)
+					}
+					return
+				}
+				if o.err != nil || o.v != "result" {
+					t.Errorf("%s caller: got %v, %v, want the shared result", name, o.v, o.err)
+				}
+			}
+
+			// A cancelled caller returns at once, while the shared call is still running
+			if tt.cancelFirst {
+				cancelFirst()
+				check("first", wait(first), true)
+			}
+			if tt.cancelSecond {
+				cancelSecond()
+				check("second", wait(second), true)
+			}
+			close(release)
+			if !tt.cancelFirst {
+				check("first", wait(first), false)
+			}
+			if !tt.cancelSecond {
+				check("second", wait(second), false)
+			}
+			if n := atomic.LoadInt32(&calls); n != 1 {
+				t.Errorf("fn ran %d times, want 1", n)
+			}
+		})
+	}
+}
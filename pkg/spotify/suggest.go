@@ -0,0 +1,105 @@
+// This file will contain the search suggestions for the typeahead of the search box.
+
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"Smart-Music-Go/pkg/music"
+
+	"github.com/zmb3/spotify/v2"
+)
+
+// Suggest returns up to limit artist and track names matching the start of query, artists first.
+// Spotify has no suggestion API, so this runs a small search and keeps only the names.
+// Suggestions are cached with the search results and concurrent identical requests share a single call.
+func (sc *SpotifyClient) Suggest(ctx context.Context, query, market string, limit int) ([]music.Suggestion, error) {
+	key := "suggest:" + market + ":" + strings.ToLower(strings.TrimSpace(query))
+	if cached, ok := sc.cachedSuggestions(ctx, key); ok {
+		return trimSuggestions(cached, limit), nil
+	}
+
+	v, err := sc.shared(ctx, key, func(ctx context.Context) (interface{}, error) {
+		suggestions, err := sc.suggest(ctx, query, market)
+		if err == nil {
+			sc.cacheSuggestions(ctx, key, suggestions)
+		}
+		return suggestions, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trimSuggestions(v.([]music.Suggestion), limit), nil
+}
+
+// maxSuggestions is how many artists and tracks are requested from Spotify for a suggestion.
+// The whole list is cached, so requests asking for fewer can share it.
+const maxSuggestions = 10
+
+// suggest searches Spotify for artists and tracks matching query, without caching
+func (sc *SpotifyClient) suggest(ctx context.Context, query, market string) ([]music.Suggestion, error) {
+	opts := append([]spotify.RequestOption{spotify.Limit(maxSuggestions)}, marketOptions(market)...)
+	results, err := sc.Client.Search(ctx, query, spotify.SearchTypeArtist|spotify.SearchTypeTrack, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []music.Suggestion{}
+	seen := make(map[string]bool)
+	add := func(text, kind string, id spotify.ID) {
+		// The same song is often released several times, one suggestion is enough
+		k := kind + ":" + strings.ToLower(text)
+		if seen[k] {
+			return
+		}
+		seen[k] = true
+		suggestions = append(suggestions, music.Suggestion{Text: text, Type: kind, ID: string(id), Provider: "spotify"})
+	}
+	if results.Artists != nil {
+		for _, a := range results.Artists.Artists {
+			add(a.Name, "artist", a.ID)
+		}
+	}
+	if results.Tracks != nil {
+		for _, t := range results.Tracks.Tracks {
+			add(t.Name, "track", t.ID)
+		}
+	}
+	return suggestions, nil
+}
+
+// trimSuggestions keeps the first limit suggestions
+func trimSuggestions(suggestions []music.Suggestion, limit int) []music.Suggestion {
+	if len(suggestions) > limit {
+		return suggestions[:limit]
+	}
+	return suggestions
+}
+
+// cachedSuggestions returns cached suggestions, treating cache failures as misses
+func (sc *SpotifyClient) cachedSuggestions(ctx context.Context, key string) ([]music.Suggestion, bool) {
+	if sc.searches == nil {
+		return nil, false
+	}
+	data, ok, err := sc.searches.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var suggestions []music.Suggestion
+	if err := json.Unmarshal(data, &suggestions); err != nil {
+		return nil, false
+	}
+	return suggestions, true
+}
+
+// cacheSuggestions stores suggestions, ignoring cache failures
+func (sc *SpotifyClient) cacheSuggestions(ctx context.Context, key string, suggestions []music.Suggestion) {
+	if sc.searches == nil {
+		return
+	}
+	if data, err := json.Marshal(suggestions); err == nil {
+		_ = sc.searches.Set(ctx, key, data)
+	}
+}
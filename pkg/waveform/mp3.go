@@ -0,0 +1,221 @@
+// This file will estimate the loudness of MP3 audio from its frame headers, without decoding the audio.
+
+package waveform
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrNotMP3 is returned when the data holds no MPEG Layer III frame
+var ErrNotMP3 = errors.New("waveform: no MP3 audio found")
+
+// bitrates are the Layer III bitrates in kbit/s by header index, for MPEG-2/2.5 and MPEG-1
+var bitrates = [2][16]int{
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+}
+
+// sampleRates are the MPEG-1 sample rates by header index, MPEG-2 halves them and MPEG-2.5 quarters them
+var sampleRates = [3]int{44100, 48000, 32000}
+
+// frameHeader holds the fields of an MPEG audio frame header needed to walk the frames
+type frameHeader struct {
+	mpeg1 bool
+	mono  bool
+	// crc is set when a 16 bit checksum sits between the header and the side information
+	crc bool
+	// length is the size of the frame in bytes, header included
+	length     int
+	sampleRate int
+	// samples is the number of samples per channel in the frame
+	samples int
+}
+
+// sideInfoSize returns the size in bytes of the side information following the header
+func (h frameHeader) sideInfoSize() int {
+	switch {
+	case h.mpeg1 && h.mono:
+		return 17
+	case h.mpeg1:
+		return 32
+	case h.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// parseHeader decodes the 4 byte header of a Layer III frame.
+// It reports false for anything else, including the rarely used free bitrate format.
+func parseHeader(b []byte) (frameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return frameHeader{}, false
+	}
+	version := b[1] >> 3 & 3 // 0 is MPEG-2.5, 1 is reserved, 2 is MPEG-2 and 3 is MPEG-1
+	layer := b[1] >> 1 & 3   // 1 is Layer III
+	bitrateIndex := b[2] >> 4
+	rateIndex := b[2] >> 2 & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return frameHeader{}, false
+	}
+
+	h := frameHeader{
+		mpeg1:      version == 3,
+		mono:       b[3]>>6 == 3,
+		crc:        b[1]&1 == 0,
+		sampleRate: sampleRates[rateIndex],
+	}
+	switch version {
+	case 2:
+		h.sampleRate /= 2
+	case 0:
+		h.sampleRate /= 4
+	}
+	padding := int(b[2] >> 1 & 1)
+	if h.mpeg1 {
+		h.samples = 1152
+		h.length = 144*bitrates[1][bitrateIndex]*1000/h.sampleRate + padding
+	} else {
+		h.samples = 576
+		h.length = 72*bitrates[0][bitrateIndex]*1000/h.sampleRate + padding
+	}
+	return h, true
+}
+
+// bitReader reads big-endian bit fields
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+// read returns the next n bits
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | int(bit)
+		r.pos++
+	}
+	return v
+}
+
+// skip moves past the next n bits
+func (r *bitReader) skip(n int) {
+	r.pos += n
+}
+
+// granuleLevels returns the level of each granule (576 samples) of a frame, from its side information.
+// The global gain an encoder picks for a granule follows its loudness, each step being 1.5 dB,
+// which is enough for a waveform. Granules without audio data are silent.
+func granuleLevels(h frameHeader, side []byte) []float64 {
+	channels := 2
+	if h.mono {
+		channels = 1
+	}
+	r := bitReader{data: side}
+	granules := 1
+	if h.mpeg1 {
+		granules = 2
+		// main_data_begin, private bits and the scale factor selection of each channel
+		r.skip(9)
+		if h.mono {
+			r.skip(5)
+		} else {
+			r.skip(3)
+		}
+		r.skip(4 * channels)
+	} else {
+		r.skip(8)
+		if h.mono {
+			r.skip(1)
+		} else {
+			r.skip(2)
+		}
+	}
+
+	levels := make([]float64, granules)
+	for gr := 0; gr < granules; gr++ {
+		for ch := 0; ch < channels; ch++ {
+			length := r.read(12) // part2_3_length
+			r.skip(9)            // big_values
+			gain := r.read(8)
+			// scalefac_compress, window switching and the fields it selects, then the flags ending the granule
+			if h.mpeg1 {
+				r.skip(4 + 1 + 22 + 3)
+			} else {
+				r.skip(9 + 1 + 22 + 2)
+			}
+			if length > 0 {
+				levels[gr] = math.Max(levels[gr], math.Exp2(float64(gain-210)/4))
+			}
+		}
+	}
+	return levels
+}
+
+// Peaks returns n peaks summarising the loudness of the MP3 audio in data, from 0 (silence) to 1 (the loudest part),
+// along with the duration of the audio. There are fewer peaks when the audio is very short.
+// ID3 tags and bytes that aren't part of a frame are skipped.
+func Peaks(data []byte, n int) ([]float64, time.Duration, error) {
+	pos := id3Size(data)
+	var levels []float64
+	var duration float64
+	for pos+4 <= len(data) {
+		h, ok := parseHeader(data[pos:])
+		start := pos + 4
+		if h.crc {
+			start += 2
+		}
+		if !ok || pos+h.length > len(data) || start+h.sideInfoSize() > pos+h.length {
+			// Not a frame, look for the next one
+			pos++
+			continue
+		}
+		levels = append(levels, granuleLevels(h, data[start:start+h.sideInfoSize()])...)
+		duration += float64(h.samples) / float64(h.sampleRate)
+		pos += h.length
+	}
+	if len(levels) == 0 {
+		return nil, 0, ErrNotMP3
+	}
+	return peaks(levels, n), time.Duration(duration * float64(time.Second)), nil
+}
+
+// id3Size returns the size of the ID3v2 tag at the start of data, zero when there is none
+func id3Size(data []byte) int {
+	if len(data) < 10 || string(data[:3]) != "ID3" {
+		return 0
+	}
+	// The size is stored on 7 bits per byte, and a footer doubles the 10 byte header
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10
+	}
+	return size
+}
+
+// peaks splits levels into n buckets and returns the loudest level of each, relative to the loudest overall
+// and rounded to three decimals
+func peaks(levels []float64, n int) []float64 {
+	if n > len(levels) {
+		n = len(levels)
+	}
+	out := make([]float64, n)
+	loudest := 0.0
+	for i := range out {
+		for _, l := range levels[i*len(levels)/n : (i+1)*len(levels)/n] {
+			out[i] = math.Max(out[i], l)
+		}
+		loudest = math.Max(loudest, out[i])
+	}
+	if loudest == 0 {
+		return out
+	}
+	for i := range out {
+		out[i] = math.Round(out[i]/loudest*1000) / 1000
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package waveform
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// bitWriter packs big-endian bit fields, the reverse of bitReader
+type bitWriter struct {
+	data []byte
+	pos  int
+}
+
+func (w *bitWriter) write(n, v int) {
+	for i := n - 1; i >= 0; i-- {
+		if v>>i&1 == 1 {
+			w.data[w.pos/8] |= 1 << (7 - w.pos%8)
+		}
+		w.pos++
+	}
+}
+
+// mpeg1Frame builds a 128 kbit/s 44.1 kHz MPEG-1 Layer III frame whose two granules have the given global gains
+func mpeg1Frame(mono bool, gains [2]int) []byte {
+	frame := make([]byte, 417)
+	frame[0], frame[1], frame[2], frame[3] = 0xFF, 0xFB, 0x90, 0x00
+	channels := 2
+	if mono {
+		frame[3] = 0xC0
+		channels = 1
+	}
+	w := bitWriter{data: frame[4:]}
+	w.write(9, 0)
+	if mono {
+		w.write(5, 0)
+	} else {
+		w.write(3, 0)
+	}
+	w.write(4*channels, 0)
+	for gr := 0; gr < 2; gr++ {
+		for ch := 0; ch < channels; ch++ {
+			w.write(12, 100) // part2_3_length, non-zero so the granule isn't silent
+			w.write(9, 0)
+			w.write(8, gains[gr])
+			w.write(4+1+22+3, 0)
+		}
+	}
+	return frame
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		ok     bool
+		want   frameHeader
+	}{
+		{"MPEG-1 stereo", []byte{0xFF, 0xFB, 0x90, 0x00}, true, frameHeader{mpeg1: true, length: 417, sampleRate: 44100, samples: 1152}},
+		{"MPEG-1 mono with padding", []byte{0xFF, 0xFB, 0x92, 0xC0}, true, frameHeader{mpeg1: true, mono: true, length: 418, sampleRate: 44100, samples: 1152}},
+		{"MPEG-1 with CRC", []byte{0xFF, 0xFA, 0x90, 0x00}, true, frameHeader{mpeg1: true, crc: true, length: 417, sampleRate: 44100, samples: 1152}},
+		{"MPEG-2 64 kbit/s 22.05 kHz", []byte{0xFF, 0xF3, 0x80, 0x00}, true, frameHeader{length: 208, sampleRate: 22050, samples: 576}},
+		{"MPEG-2.5 8 kbit/s 11.025 kHz", []byte{0xFF, 0xE3, 0x10, 0x00}, true, frameHeader{length: 52, sampleRate: 11025, samples: 576}},
+		{"Layer II", []byte{0xFF, 0xFD, 0x90, 0x00}, false, frameHeader{}},
+		{"reserved version", []byte{0xFF, 0xEB, 0x90, 0x00}, false, frameHeader{}},
+		{"free bitrate", []byte{0xFF, 0xFB, 0x00, 0x00}, false, frameHeader{}},
+		{"bad bitrate", []byte{0xFF, 0xFB, 0xF0, 0x00}, false, frameHeader{}},
+		{"reserved sample rate", []byte{0xFF, 0xFB, 0x9C, 0x00}, false, frameHeader{}},
+		{"no sync", []byte{0xFF, 0x1B, 0x90, 0x00}, false, frameHeader{}},
+		{"truncated", []byte{0xFF, 0xFB, 0x90}, false, frameHeader{}},
+		{"empty", nil, false, frameHeader{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseHeader(tt.header)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseHeader(%x) = %+v, %v, want %+v, %v", tt.header, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPeaks(t *testing.T) {
+	var rising []byte
+	for i := 0; i < 10; i++ {
+		rising = append(rising, mpeg1Frame(i%2 == 1, [2]int{190 + i, 190 + i})...)
+	}
+	one := mpeg1Frame(false, [2]int{200, 204})
+	frameDuration := 1152 * time.Second / 44100
+
+	tests := []struct {
+		name     string
+		data     []byte
+		n        int
+		want     []float64
+		duration time.Duration
+		err      error
+	}{
+		{"empty", nil, 4, nil, 0, ErrNotMP3},
+		{"not audio", []byte("not an mp3 at all"), 4, nil, 0, ErrNotMP3},
+		{"header only", one[:4], 4, nil, 0, ErrNotMP3},
+		{"truncated side information", one[:20], 4, nil, 0, ErrNotMP3},
+		{"truncated frame", one[:400], 4, nil, 0, ErrNotMP3},
+		{"ID3 tag larger than the data", append([]byte("ID3\x03\x00\x00\x00\x00\x7F\x7F"), one...), 4, nil, 0, ErrNotMP3},
+		{"single frame", one, 4, []float64{0.5, 1}, frameDuration, nil},
+		{"ID3 tag and trailing garbage", append(append([]byte("ID3\x03\x00\x00\x00\x00\x00\x02xx"), one...), "TAG"...), 4, []float64{0.5, 1}, frameDuration, nil},
+		{"last frame truncated", append(append([]byte{}, one...), one[:100]...), 4, []float64{0.5, 1}, frameDuration, nil},
+		{"buckets keep the loudest granule", rising, 5, []float64{0.25, 0.354, 0.5, 0.707, 1}, 10 * frameDuration, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, duration, err := Peaks(tt.data, tt.n)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Peaks() error = %v, want %v", err, tt.err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Peaks() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Peaks() = %v, want %v", got, tt.want)
+				}
+			}
+			// Durations are summed frame by frame, allow for rounding
+			if diff := duration - tt.duration; diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("Peaks() duration = %v, want %v", duration, tt.duration)
+			}
+		})
+	}
+}
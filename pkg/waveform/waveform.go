@@ -0,0 +1,128 @@
+// This file will contain the generator of track waveforms, computed from the preview audio and cached.
+
+package waveform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"Smart-Music-Go/pkg/cache"
+	"Smart-Music-Go/pkg/music"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// peakCount is the number of peaks of a waveform, enough for a player a few hundred pixels wide
+	peakCount = 200
+	// maxPreviewBytes bounds the download of a preview, 30 seconds of MP3 are well under a megabyte
+	maxPreviewBytes = 4 << 20
+	// downloadTimeout bounds the download and analysis of a preview
+	downloadTimeout = 20 * time.Second
+)
+
+// Generator computes the waveform of tracks from their preview audio
+type Generator struct {
+	client *http.Client
+	// cache keeps the waveform of each track when EnableCache was called, nil otherwise
+	cache cache.Store
+	// inflight merges requests for the same track so its preview is downloaded once
+	inflight singleflight.Group
+}
+
+// New creates a Generator. Previews are downloaded through base, which may be nil to use http.DefaultTransport.
+func New(base http.RoundTripper) *Generator {
+	return &Generator{client: &http.Client{Transport: base, Timeout: downloadTimeout}}
+}
+
+// EnableCache keeps the waveform of each track in store.
+// A preview doesn't change, so the store can keep them for a long time.
+func (g *Generator) EnableCache(store cache.Store) {
+	g.cache = store
+}
+
+// Cached returns the waveform of a track if it was already computed, so the preview doesn't need to be looked up
+func (g *Generator) Cached(ctx context.Context, trackID string) (music.Waveform, bool) {
+	if g.cache == nil {
+		return music.Waveform{}, false
+	}
+	data, ok, err := g.cache.Get(ctx, trackID)
+	if err != nil || !ok {
+		return music.Waveform{}, false
+	}
+	var w music.Waveform
+	if err := json.Unmarshal(data, &w); err != nil {
+		return music.Waveform{}, false
+	}
+	return w, true
+}
+
+// Generate downloads the MP3 preview at previewURL and returns the waveform of the track,
+// which is cached for the next requests.
+// Concurrent requests for the same track share the download, which runs detached from them, bounded by downloadTimeout,
+// so one of them going away doesn't fail the others. Each caller stops waiting as soon as its own ctx is done.
+func (g *Generator) Generate(ctx context.Context, trackID, previewURL, provider string) (music.Waveform, error) {
+	ch := g.inflight.DoChan(trackID, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), downloadTimeout)
+		defer cancel()
+		data, err := g.download(ctx, previewURL)
+		if err != nil {
+			return nil, err
+		}
+		peaks, duration, err := Peaks(data, peakCount)
+		if err != nil {
+			return nil, err
+		}
+		w := music.Waveform{TrackID: trackID, Peaks: peaks, DurationMS: int(duration.Milliseconds()), Provider: provider}
+		g.store(ctx, w)
+		return w, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return music.Waveform{}, res.Err
+		}
+		return res.Val.(music.Waveform), nil
+	case <-ctx.Done():
+		return music.Waveform{}, ctx.Err()
+	}
+}
+
+// download returns the content at url, refusing anything larger than a preview should be
+func (g *Generator) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("waveform: downloading preview: unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPreviewBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPreviewBytes {
+		return nil, fmt.Errorf("waveform: preview is larger than %d bytes", maxPreviewBytes)
+	}
+	return data, nil
+}
+
+// store caches the waveform of a track, ignoring cache failures
+func (g *Generator) store(ctx context.Context, w music.Waveform) {
+	if g.cache == nil {
+		return
+	}
+	if data, err := json.Marshal(w); err == nil {
+		_ = g.cache.Set(ctx, w.TrackID, data)
+	}
+}